@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+// recordFieldsUsage contains format hints for the record flags
+var recordFieldsUsage = map[string]string{
+	"date":           "Date of the flight `DD/MM/YYYY`",
+	"departure-time": "Departure time `HHMM`",
+	"arrival-time":   "Arrival time `HHMM`",
+	"landings-day":   "Number of day landings",
+	"landings-night": "Number of night landings",
+	"sim-type":       "Simulator type",
+	"pic-name":       "Name of the PIC",
+	"remarks":        "Remarks and endorsements",
+}
+
+// addCmd represents the add command
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a new record to the xlsx logbook",
+	Run:   addRun,
+}
+
+func addRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.Record = recordFields(cmd.Flags())
+
+	logbook.AddRecord(logbookConfig)
+}
+
+// addRecordFlags adds a flag for every logbook record field
+func addRecordFlags(flags *pflag.FlagSet) {
+	for _, name := range logbook.RecordFields {
+		usage, ok := recordFieldsUsage[name]
+		if !ok {
+			usage = fmt.Sprintf("Value of the %s field", name)
+		}
+
+		flags.String(name, "", usage)
+	}
+}
+
+// recordFields returns the record fields which were set in the command line
+func recordFields(flags *pflag.FlagSet) map[string]string {
+	fields := make(map[string]string)
+	for _, name := range logbook.RecordFields {
+		if flags.Changed(name) {
+			fields[name], _ = flags.GetString(name)
+		}
+	}
+	return fields
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+
+	addRecordFlags(addCmd.Flags())
+}
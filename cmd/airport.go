@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+// airportCmd represents the airport command
+var airportCmd = &cobra.Command{
+	Use:   "airport CODE",
+	Short: "Show airport details by ICAO or IATA code",
+	Args:  cobra.ExactArgs(1),
+	Run:   airportRun,
+}
+
+func airportRun(cmd *cobra.Command, args []string) {
+
+	logbook.LookupAirport(newLogbookConfig(), args[0])
+}
+
+func init() {
+	rootCmd.AddCommand(airportCmd)
+
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+// airportsCmd represents the airports command
+var airportsCmd = &cobra.Command{
+	Use:   "airports",
+	Short: "Manage airports database",
+}
+
+// airportsUpdateCmd represents the airports update command
+var airportsUpdateCmd = &cobra.Command{
+	Use:   "update FILE|URL",
+	Short: "Update airports database from OurAirports airports.csv file",
+	Args:  cobra.ExactArgs(1),
+	Run:   airportsUpdateRun,
+}
+
+func airportsUpdateRun(cmd *cobra.Command, args []string) {
+
+	logbook.UpdateAirports(newLogbookConfig(), args[0])
+}
+
+func init() {
+	rootCmd.AddCommand(airportsCmd)
+	airportsCmd.AddCommand(airportsUpdateCmd)
+
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Authorize access to the private google spreadsheet with your google account",
+	Long: `Authorize access to the private google spreadsheet with your google account.
+Opens the link in the browser and saves the oauth token, the "auth" parameter must be "oauth"`,
+	Run: authRun,
+}
+
+func authRun(cmd *cobra.Command, args []string) {
+
+	if googleAuth != "oauth" {
+		log.Fatalf("The 'auth' value in the %s should be oauth", cfgFile)
+	}
+	verifyParameter(credentialsFile, "credentials_file")
+
+	logbook.Authorize(newLogbookConfig())
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var checkTolerance int
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check logbook records, e.g. compare night time with the calculated one",
+	Run:   checkRun,
+}
+
+func checkRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.CheckTolerance = time.Duration(checkTolerance) * time.Minute
+
+	logbook.Check(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().IntVarP(&checkTolerance, "tolerance", "t", 5, "Allowed difference in `MINUTES` between the logbook and calculated times")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a record from the xlsx logbook",
+	Run:   deleteRun,
+}
+
+func deleteRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.Row = recordRow
+
+	logbook.DeleteRecord(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+
+	deleteCmd.Flags().IntVarP(&recordRow, "row", "r", 0, "Number of the `ROW` in the sheet with the record")
+	deleteCmd.MarkFlagRequired("row")
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var recordRow int
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Update a record in the xlsx logbook",
+	Run:   editRun,
+}
+
+func editRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.Row = recordRow
+	logbookConfig.Record = recordFields(cmd.Flags())
+
+	logbook.EditRecord(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	editCmd.Flags().IntVarP(&recordRow, "row", "r", 0, "Number of the `ROW` in the sheet with the record")
+	editCmd.MarkFlagRequired("row")
+	addRecordFlags(editCmd.Flags())
+}
@@ -1,13 +1,16 @@
 package cmd
 
 import (
-	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/vsimakhin/logbook/logbook"
 )
 
+var fillNight bool
+var fillTimes bool
+var exportMap bool
+
 // exportCmd represents the export command
 var exportCmd = &cobra.Command{
 	Use:   "export",
@@ -23,18 +26,12 @@ func exportRun(cmd *cobra.Command, args []string) {
 	verifyParameter(pageBrakes, "page_brakes")
 	verifyParameter(reverseEntries, "reverse")
 
-	reverse, _ := strconv.ParseBool(reverseEntries)
-
-	logbookConfig := logbook.LogbookConfig{
-		SourceType:    sourceType,
-		FileName:      fileName,
-		APIKey:        apiKey,
-		SpreadsheetID: spreadsheetId,
-		StartRow:      startRow,
-		LogbookOwner:  logbookOwner,
-		PageBrakes:    strings.Split(pageBrakes, ","),
-		Reverse:       reverse,
-	}
+	logbookConfig := newLogbookConfig()
+	logbookConfig.LogbookOwner = logbookOwner
+	logbookConfig.PageBrakes = strings.Split(pageBrakes, ",")
+	logbookConfig.FillNight = fillNight
+	logbookConfig.FillTimes = fillTimes
+	logbookConfig.ExportMap = exportMap
 
 	logbook.Export(logbookConfig)
 }
@@ -42,4 +39,7 @@ func exportRun(cmd *cobra.Command, args []string) {
 func init() {
 	rootCmd.AddCommand(exportCmd)
 
+	exportCmd.Flags().BoolVar(&fillTimes, "fill-times", false, "Fill empty total time from departure and arrival times and SE, ME or MCC time from the aircraft types")
+	exportCmd.Flags().BoolVar(&exportMap, "map", false, "Add the page with the map of all flights to the end of the logbook")
+	exportCmd.Flags().BoolVar(&fillNight, "fill-night", false, "Fill empty night time with the time calculated from the airports coordinates")
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var geoOutput string
+
+// exportGeoCmd represents the export-geo command
+var exportGeoCmd = &cobra.Command{
+	Use:   "export-geo",
+	Short: "Export visited airports and routes to GeoJSON, KML or GPX",
+	Run:   exportGeoRun,
+}
+
+func exportGeoRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.FilterDate = filterDate
+	logbookConfig.FilterNoRoutes = noRoutes
+	logbookConfig.OutputFile = geoOutput
+
+	logbook.ExportGeo(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(exportGeoCmd)
+
+	exportGeoCmd.Flags().StringVarP(&geoOutput, "output", "o", "logbook.geojson", "Output `FILE`, the format is geojson, kml or gpx by the extension")
+	exportGeoCmd.Flags().StringVarP(&filterDate, "filter-date", "d", "", "Set filter for the `DATE` logbook field")
+	exportGeoCmd.Flags().BoolVar(&noRoutes, "no-routes", false, "Export airports only")
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var importFormat string
+var outputFile string
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import logbook records from ForeFlight, LogTen Pro or mccPILOTLOG csv export",
+	Args:  cobra.ExactArgs(1),
+	Run:   importRun,
+}
+
+func importRun(cmd *cobra.Command, args []string) {
+
+	reverse, _ := strconv.ParseBool(reverseEntries)
+
+	logbookConfig := logbook.LogbookConfig{
+		ImportFormat: importFormat,
+		ImportFile:   args[0],
+		OutputFile:   outputFile,
+		Reverse:      reverse,
+	}
+
+	logbook.Import(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "foreflight", "Format of the csv `FORMAT`: foreflight, logten or mccpilotlog")
+	importCmd.Flags().StringVarP(&outputFile, "output", "o", "logbook-imported.xlsx", "Output xlsx `FILE`")
+}
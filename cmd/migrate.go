@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var dbFile string
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate logbook records from google spreadsheet or xlsx file to the local database",
+	Run:   migrateRun,
+}
+
+func migrateRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	verifyParameter(reverseEntries, "reverse")
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.OutputFile = dbFile
+
+	logbook.Migrate(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().StringVar(&dbFile, "db", "logbook.db", "Database `FILE` to migrate the records to")
+}
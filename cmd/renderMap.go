@@ -1,12 +1,32 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/vsimakhin/logbook/logbook"
 )
 
 var filterDate string
 var noRoutes bool
+var mapLegend bool
+var mapColorBy string
+var mapRouteWeight bool
+var mapMBTiles string
+var mapOutput string
+var mapWidth int
+var mapHeight int
+var mapDPI float64
+var mapLabels string
+var mapMarkerScale bool
+var mapPadding int
+var mapHomeBase string
+var mapTimeline string
+var mapFrameDelay int
+var mapHeatmap bool
+var heatmapRadius float64
+var heatmapColors string
+var mapCountries string
 
 // renderMapCmd represents the renderMap command
 var renderMapCmd = &cobra.Command{
@@ -19,14 +39,32 @@ func renderMapRun(cmd *cobra.Command, args []string) {
 
 	verifyConfig()
 
-	logbookConfig := logbook.LogbookConfig{
-		SourceType:     sourceType,
-		FileName:       fileName,
-		APIKey:         apiKey,
-		SpreadsheetID:  spreadsheetId,
-		StartRow:       startRow,
-		FilterDate:     filterDate,
-		FilterNoRoutes: noRoutes,
+	logbookConfig := newLogbookConfig()
+	logbookConfig.FilterDate = filterDate
+	logbookConfig.FilterNoRoutes = noRoutes
+	logbookConfig.MapLegend = mapLegend
+	logbookConfig.MapColorBy = mapColorBy
+	logbookConfig.MapRouteWeight = mapRouteWeight
+	logbookConfig.OutputFile = mapOutput
+	logbookConfig.MapWidth = mapWidth
+	logbookConfig.MapHeight = mapHeight
+	logbookConfig.MapDPI = mapDPI
+	logbookConfig.MapLabels = mapLabels
+	logbookConfig.MapMarkerScale = mapMarkerScale
+	logbookConfig.MapPadding = mapPadding
+	logbookConfig.MapTimeline = mapTimeline
+	logbookConfig.MapFrameDelay = mapFrameDelay
+	logbookConfig.MapHeatmap = mapHeatmap
+	logbookConfig.HeatmapRadius = heatmapRadius
+	if heatmapColors != "" {
+		logbookConfig.HeatmapColors = strings.Split(heatmapColors, ",")
+	}
+	if mapHomeBase != "" {
+		logbookConfig.HomeBase = mapHomeBase
+	}
+	logbookConfig.MapCountries = mapCountries
+	if mapMBTiles != "" {
+		logbookConfig.MBTilesFile = mapMBTiles
 	}
 
 	logbook.RendersMap(logbookConfig)
@@ -37,4 +75,22 @@ func init() {
 
 	renderMapCmd.Flags().StringVarP(&filterDate, "filter-date", "d", "", "Set filter for the `DATE` logbook field for map rendering")
 	renderMapCmd.Flags().BoolVar(&noRoutes, "no-routes", false, "Skip rendering routes on the map")
+	renderMapCmd.Flags().BoolVar(&mapLegend, "legend", false, "Add legend with totals and distances to the map")
+	renderMapCmd.Flags().StringVar(&mapColorBy, "color-by", "", "Colour routes and markers by `MODE`: type, year or night")
+	renderMapCmd.Flags().BoolVar(&mapRouteWeight, "route-weight", false, "Draw the most flown routes wider and more opaque")
+	renderMapCmd.Flags().StringVarP(&mapOutput, "output", "o", "map.png", "Output `FILE`, the format is png, svg or pdf by the extension")
+	renderMapCmd.Flags().IntVar(&mapWidth, "width", 1920, "Map width in pixels")
+	renderMapCmd.Flags().IntVar(&mapHeight, "height", 1080, "Map height in pixels")
+	renderMapCmd.Flags().Float64Var(&mapDPI, "dpi", 96, "Map resolution, the routes, markers and legend are scaled from 96 dpi")
+	renderMapCmd.Flags().StringVar(&mapLabels, "labels", "", "Label airports with `CODE`: icao or iata")
+	renderMapCmd.Flags().BoolVar(&mapMarkerScale, "marker-scale", false, "Scale airport markers by the number of visits")
+	renderMapCmd.Flags().StringVar(&mapHomeBase, "home-base", "", "Highlight the home base airport `CODE`")
+	renderMapCmd.Flags().IntVar(&mapPadding, "padding", 20, "Padding in pixels around airports and routes")
+	renderMapCmd.Flags().StringVar(&mapTimeline, "timeline", "", "Render animated gif or png map with one frame per `PERIOD`: month or year")
+	renderMapCmd.Flags().IntVar(&mapFrameDelay, "frame-delay", 500, "Delay between the timeline frames in milliseconds")
+	renderMapCmd.Flags().BoolVar(&mapHeatmap, "heatmap", false, "Draw the heatmap of the airport visits instead of the markers")
+	renderMapCmd.Flags().Float64Var(&heatmapRadius, "heatmap-radius", 40, "Heatmap radius of one airport in pixels")
+	renderMapCmd.Flags().StringVar(&heatmapColors, "heatmap-colors", "", "Comma separated heatmap `COLORS` from the rarely to the most visited, e.g. \"#0000ff,#ffff00,#ff0000\"")
+	renderMapCmd.Flags().StringVar(&mapCountries, "countries", "", "Shade the visited countries with the boundaries from the GeoJSON `FILE`")
+	renderMapCmd.Flags().StringVar(&mapMBTiles, "mbtiles", "", "Render the map from the local MBTiles `FILE` instead of the tile server")
 }
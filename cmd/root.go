@@ -5,8 +5,10 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
@@ -21,6 +23,50 @@ var pageBrakes string
 var reverseEntries string
 var sourceType string
 var fileName string
+var cacheDir string
+var googleEndpoint string
+var googleAuth string
+var credentialsFile string
+var tokenFile string
+var sheetName string
+var sources []sourceConfig
+var aircraftTypes map[string]aircraftType
+var aircraftList []aircraftConfig
+var aircraftSheet string
+var airportsDB string
+var customAirports string
+var tileURL string
+var tileAttribution string
+var tileShards []string
+var tileSize int
+var mbtilesFile string
+var homeBase string
+var apiTokens []string
+
+// sourceConfig is one of the logbook sources in the config file
+type sourceConfig struct {
+	Type          string `mapstructure:"type"`
+	FileName      string `mapstructure:"file_name"`
+	SpreadsheetID string `mapstructure:"spreadsheet_id"`
+	SheetName     string `mapstructure:"sheet_name"`
+	StartRow      int    `mapstructure:"start_row"`
+}
+
+// aircraftType is the class of the aircraft model in the config file
+type aircraftType struct {
+	Engine     string `mapstructure:"engine"`
+	MultiPilot bool   `mapstructure:"multi_pilot"`
+	Category   string `mapstructure:"category"`
+}
+
+// aircraftConfig is the aircraft in the registry in the config file
+type aircraftConfig struct {
+	Registration string `mapstructure:"reg"`
+	Model        string `mapstructure:"model"`
+	Engine       string `mapstructure:"engine"`
+	MultiPilot   bool   `mapstructure:"multi_pilot"`
+	Category     string `mapstructure:"category"`
+}
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -100,6 +146,100 @@ func initConfig() {
 		reverseEntries = viper.GetString("reverse")
 		sourceType = viper.GetString("type")
 		fileName = viper.GetString("file_name")
+		cacheDir = viper.GetString("cache_dir")
+		googleEndpoint = viper.GetString("google_endpoint")
+		googleAuth = viper.GetString("auth")
+		credentialsFile = viper.GetString("credentials_file")
+		tokenFile = viper.GetString("token_file")
+		sheetName = viper.GetString("sheet_name")
+		aircraftSheet = viper.GetString("aircraft_sheet")
+		airportsDB = viper.GetString("airports_db")
+		customAirports = viper.GetString("custom_airports")
+		tileURL = viper.GetString("tile_url")
+		tileAttribution = viper.GetString("tile_attribution")
+		tileShards = viper.GetStringSlice("tile_shards")
+		tileSize = viper.GetInt("tile_size")
+		mbtilesFile = viper.GetString("mbtiles")
+		homeBase = viper.GetString("home_base")
+		apiTokens = viper.GetStringSlice("api_tokens")
+
+		if err := viper.UnmarshalKey("sources", &sources); err != nil {
+			log.Fatalf("Error reading sources from the config file: %v\n", err)
+		}
+
+		if err := viper.UnmarshalKey("aircraft_types", &aircraftTypes); err != nil {
+			log.Fatalf("Error reading aircraft types from the config file: %v\n", err)
+		}
+
+		if err := viper.UnmarshalKey("aircraft", &aircraftList); err != nil {
+			log.Fatalf("Error reading aircraft from the config file: %v\n", err)
+		}
+	}
+}
+
+// newLogbookConfig returns the logbook config with the source parameters
+func newLogbookConfig() logbook.LogbookConfig {
+	reverse, _ := strconv.ParseBool(reverseEntries)
+
+	var logbookSources []logbook.SourceConfig
+	for _, source := range sources {
+		logbookSources = append(logbookSources, logbook.SourceConfig{
+			SourceType:    source.Type,
+			FileName:      source.FileName,
+			SpreadsheetID: source.SpreadsheetID,
+			SheetName:     source.SheetName,
+			StartRow:      source.StartRow,
+		})
+	}
+
+	logbookAircraftTypes := make(map[string]logbook.AircraftType)
+	for model, aircraft := range aircraftTypes {
+		logbookAircraftTypes[model] = logbook.AircraftType{
+			Engine:     aircraft.Engine,
+			MultiPilot: aircraft.MultiPilot,
+			Category:   aircraft.Category,
+		}
+	}
+
+	var logbookAircraft []logbook.Aircraft
+	for _, aircraft := range aircraftList {
+		logbookAircraft = append(logbookAircraft, logbook.Aircraft{
+			Registration: aircraft.Registration,
+			Model:        aircraft.Model,
+			AircraftType: logbook.AircraftType{
+				Engine:     aircraft.Engine,
+				MultiPilot: aircraft.MultiPilot,
+				Category:   aircraft.Category,
+			},
+		})
+	}
+
+	return logbook.LogbookConfig{
+		SourceType:      sourceType,
+		FileName:        fileName,
+		APIKey:          apiKey,
+		SpreadsheetID:   spreadsheetId,
+		StartRow:        startRow,
+		Reverse:         reverse,
+		CacheDir:        cacheDir,
+		GoogleEndpoint:  googleEndpoint,
+		GoogleAuth:      googleAuth,
+		CredentialsFile: credentialsFile,
+		TokenFile:       tokenFile,
+		SheetName:       sheetName,
+		Sources:         logbookSources,
+		AircraftTypes:   logbookAircraftTypes,
+		Aircraft:        logbookAircraft,
+		AircraftSheet:   aircraftSheet,
+		AirportsDB:      airportsDB,
+		CustomAirports:  customAirports,
+		TileURL:         tileURL,
+		TileAttribution: tileAttribution,
+		TileShards:      tileShards,
+		TileSize:        tileSize,
+		MBTilesFile:     mbtilesFile,
+		HomeBase:        homeBase,
+		APITokens:       apiTokens,
 	}
 }
 
@@ -117,20 +257,48 @@ func verifyParameter(param interface{}, name string) {
 // in the configuration file
 func verifyConfig() {
 
+	if len(sources) > 0 {
+		for _, source := range sources {
+			// the start row can be set for all sources
+			sourceStartRow := source.StartRow
+			if sourceStartRow == 0 {
+				sourceStartRow = startRow
+			}
+			verifySource(source.Type, source.FileName, source.SpreadsheetID, sourceStartRow)
+		}
+		return
+	}
+
+	verifySource(sourceType, fileName, spreadsheetId, startRow)
+}
+
+// verifySource checks the parameters of one logbook source
+func verifySource(sourceType string, fileName string, spreadsheetId string, startRow int) {
+
 	verifyParameter(sourceType, "type")
 
-	if sourceType == "xlsx" {
+	if sourceType == "xlsx" || sourceType == "csv" {
 		verifyParameter(fileName, "file_name")
 
 	} else if sourceType == "google" {
-		verifyParameter(apiKey, "api_key")
+		if googleAuth == "" || googleAuth == "api_key" {
+			verifyParameter(apiKey, "api_key")
+		} else if googleAuth == "service_account" || googleAuth == "oauth" {
+			verifyParameter(credentialsFile, "credentials_file")
+		} else {
+			log.Fatalf("unknown auth method in the %s config file", cfgFile)
+		}
 		verifyParameter(spreadsheetId, "spreadsheet_id")
 
+	} else if sourceType == "db" {
+		verifyParameter(fileName, "file_name")
+		// there are no header rows in the database
+		return
+
 	} else {
 		log.Fatalf("unknown type for the source in the %s config file", cfgFile)
 
 	}
 
 	verifyParameter(startRow, "start_row")
-
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var serveAddress string
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start web server to browse the logbook",
+	Run:   serveRun,
+}
+
+func serveRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.ServeAddress = serveAddress
+
+	logbook.Serve(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddress, "address", "localhost:8080", "Listen on `ADDRESS`, use 0.0.0.0:8080 to open the logbook to the network")
+}
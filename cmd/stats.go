@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var showDistances bool
+var showRegions bool
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show logbook statistics",
+	Run:   statsRun,
+}
+
+func statsRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+	logbookConfig.ShowDistances = showDistances
+	logbookConfig.ShowRegions = showRegions
+
+	logbook.Stats(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().BoolVar(&showDistances, "distances", false, "Show the distance of every flight")
+	statsCmd.Flags().BoolVar(&showRegions, "regions", false, "Show the totals of every visited region")
+}
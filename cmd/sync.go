@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync google spreadsheet to the local cache for the offline usage",
+	Run:   syncRun,
+}
+
+func syncRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbook.Sync(newLogbookConfig())
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+}
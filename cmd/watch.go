@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vsimakhin/logbook/logbook"
+)
+
+var watchPDF string
+var watchMap string
+var watchStats string
+var watchDelay int
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Regenerate pdf, map and stats every time the logbook file is changed",
+	Run:   watchRun,
+}
+
+func watchRun(cmd *cobra.Command, args []string) {
+
+	verifyConfig()
+
+	logbookConfig := newLogbookConfig()
+
+	if watchPDF != "" {
+		verifyParameter(logbookOwner, "owner")
+		verifyParameter(pageBrakes, "page_brakes")
+		verifyParameter(reverseEntries, "reverse")
+
+		logbookConfig.LogbookOwner = logbookOwner
+		logbookConfig.PageBrakes = strings.Split(pageBrakes, ",")
+	}
+
+	logbookConfig.WatchPDF = watchPDF
+	logbookConfig.WatchMap = watchMap
+	logbookConfig.WatchStats = watchStats
+	logbookConfig.WatchDelay = watchDelay
+
+	// the same defaults as for the render-map command
+	logbookConfig.MapWidth = 1920
+	logbookConfig.MapHeight = 1080
+	logbookConfig.MapDPI = 96
+	logbookConfig.MapPadding = 20
+
+	logbook.Watch(logbookConfig)
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVar(&watchPDF, "pdf", "logbook.pdf", "Export the logbook to the pdf `FILE`, set empty to skip")
+	watchCmd.Flags().StringVar(&watchMap, "map", "", "Render the map of all flights to the `FILE`, the format is png, svg or pdf by the extension")
+	watchCmd.Flags().StringVar(&watchStats, "stats", "", "Save the totals, the countries and the currency to the json `FILE`")
+	watchCmd.Flags().IntVar(&watchDelay, "delay", 1000, "Wait for the next changes for the delay in milliseconds before regenerating")
+}
@@ -0,0 +1,247 @@
+package logbook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AircraftType is the class of the aircraft model, it defines which of
+// SE, ME and MCC times should be logged for the flight
+type AircraftType struct {
+	Engine     string
+	MultiPilot bool
+	Category   string
+}
+
+// Aircraft is the aircraft in the registry
+type Aircraft struct {
+	Registration string
+	Model        string
+	AircraftType
+}
+
+// aircraftRegistry contains the aircraft by registration and the default classes by model
+type aircraftRegistry struct {
+	aircraft map[string]Aircraft
+	types    map[string]AircraftType
+}
+
+// aircraftSheetStartRow is the first row with aircraft in the aircraft sheet, the first one is a header
+var aircraftSheetStartRow = 2
+
+// parseAircraftRow returns the aircraft from the aircraft sheet row in the layout
+// registration, model, engine, multi pilot, category
+func parseAircraftRow(row []interface{}) Aircraft {
+	cells := make([]string, 5)
+	for i := 0; i < len(row) && i < len(cells); i++ {
+		cells[i] = strings.TrimSpace(fmt.Sprintf("%v", row[i]))
+	}
+
+	var aircraft Aircraft
+	aircraft.Registration = cells[0]
+	aircraft.Model = cells[1]
+	aircraft.Engine = strings.ToLower(cells[2])
+	aircraft.MultiPilot = parseImportBool(cells[3])
+	aircraft.Category = cells[4]
+
+	return aircraft
+}
+
+// loadAircraftRegistry returns the registry with the aircraft from the config file and
+// from the aircraft sheet of the logbook in case it's set
+func loadAircraftRegistry(logbookConfig LogbookConfig) (aircraftRegistry, error) {
+	registry := aircraftRegistry{
+		aircraft: make(map[string]Aircraft),
+		types:    make(map[string]AircraftType),
+	}
+
+	for model, aircraftType := range logbookConfig.AircraftTypes {
+		registry.types[model] = aircraftType
+	}
+
+	aircraftList := logbookConfig.Aircraft
+
+	if logbookConfig.AircraftSheet != "" {
+		if logbookConfig.SourceType != "xlsx" && logbookConfig.SourceType != "google" {
+			return registry, fmt.Errorf("aircraft sheet can be used with xlsx or google source only")
+		}
+
+		sheetConfig := logbookConfig
+		sheetConfig.SheetName = logbookConfig.AircraftSheet
+		sheetConfig.StartRow = aircraftSheetStartRow
+
+		rows, err := getSourceDump(sheetConfig)
+		if err != nil {
+			return registry, fmt.Errorf("cannot read aircraft sheet: %v", err)
+		}
+
+		for _, row := range rows {
+			aircraftList = append(aircraftList, parseAircraftRow(row))
+		}
+	}
+
+	for _, aircraft := range aircraftList {
+		if aircraft.Registration == "" {
+			continue
+		}
+		registry.aircraft[aircraft.Registration] = aircraft
+	}
+
+	return registry, nil
+}
+
+// lookup returns the aircraft of the flight by registration or the default class by model
+func (registry aircraftRegistry) lookup(record logbookRecord) (Aircraft, bool) {
+	if aircraft, ok := registry.aircraft[record.aircraft.reg]; ok {
+		return aircraft, true
+	}
+
+	if aircraftType, ok := registry.types[record.aircraft.model]; ok {
+		return Aircraft{Registration: record.aircraft.reg, Model: record.aircraft.model, AircraftType: aircraftType}, true
+	}
+
+	return Aircraft{}, false
+}
+
+// className returns the name of the aircraft class for the stats
+func (aircraft Aircraft) className() string {
+	var parts []string
+
+	if aircraft.Category != "" {
+		parts = append(parts, aircraft.Category)
+	}
+
+	if aircraft.Engine != "" {
+		parts = append(parts, strings.ToUpper(aircraft.Engine))
+	}
+
+	if aircraft.MultiPilot {
+		parts = append(parts, "multi pilot")
+	} else {
+		parts = append(parts, "single pilot")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// classTimes returns the expected SE, ME and MCC times for the flight
+//
+// aircraftType AircraftType - class of the aircraft
+//
+// total time.Duration - total time of the flight
+func classTimes(aircraftType AircraftType, total time.Duration) (se time.Duration, me time.Duration, mcc time.Duration) {
+	if aircraftType.MultiPilot {
+		// me time is not shown for the multi pilot flights
+		return 0, 0, total
+	}
+
+	if strings.ToLower(aircraftType.Engine) == "me" {
+		return 0, total, 0
+	}
+
+	return total, 0, 0
+}
+
+// fillAircraft sets the aircraft model from the registry in case it's not set
+func (registry aircraftRegistry) fillAircraft(record *logbookRecord) {
+	if record.aircraft.model != "" {
+		return
+	}
+
+	if aircraft, ok := registry.aircraft[record.aircraft.reg]; ok {
+		record.aircraft.model = aircraft.Model
+	}
+}
+
+// checkAircraftClass returns the check which compares the aircraft model with the registry
+// and SE, ME and MCC times with the aircraft class
+//
+// registry aircraftRegistry - aircraft registry
+func checkAircraftClass(registry aircraftRegistry) recordCheck {
+	return func(record logbookRecord) string {
+		aircraft, ok := registry.lookup(record)
+		if !ok || record.time.total.time == 0 {
+			return ""
+		}
+
+		var issues []string
+
+		if aircraft.Model != "" && aircraft.Model != record.aircraft.model {
+			issues = append(issues, fmt.Sprintf("model is %s, expected %s for %s", record.aircraft.model, aircraft.Model, record.aircraft.reg))
+		}
+
+		se, me, mcc := classTimes(aircraft.AircraftType, record.time.total.time)
+
+		var timeIssues []string
+		for _, column := range []struct {
+			name     string
+			time     logbookTime
+			expected time.Duration
+		}{
+			{"SE", record.time.se, se},
+			{"ME", record.time.me, me},
+			{"MCC", record.time.mcc, mcc},
+		} {
+			if column.time.time != column.expected {
+				expected := logbookTime{time: column.expected}
+				timeIssues = append(timeIssues, fmt.Sprintf("%s time is %s, expected %s", column.name, column.time.GetTime(true), expected.GetTime(true)))
+			}
+		}
+
+		if len(timeIssues) > 0 {
+			issues = append(issues, fmt.Sprintf("%s for %s", strings.Join(timeIssues, ", "), aircraft.Model))
+		}
+
+		return strings.Join(issues, "; ")
+	}
+}
+
+// classTotals contains the totals of the flights in the aircraft class
+type classTotals struct {
+	class   string
+	flights int
+	totals  logbookTotalRecord
+}
+
+// calculateClassTotals groups the flights by the aircraft class, the simulator sessions
+// and the flights on the aircraft which are not in the registry are grouped separately
+//
+// rows [][]interface{} - logbook dump
+//
+// registry aircraftRegistry - aircraft registry
+func calculateClassTotals(rows [][]interface{}, registry aircraftRegistry) []classTotals {
+	classes := make(map[string]*classTotals)
+
+	for _, row := range rows {
+		record := parseRecord(row)
+
+		class := "Simulator"
+		if record.sim.name == "" {
+			if aircraft, ok := registry.lookup(record); ok {
+				class = aircraft.className()
+			} else {
+				class = "Unknown"
+			}
+		}
+
+		if _, ok := classes[class]; !ok {
+			classes[class] = &classTotals{class: class}
+		}
+
+		classes[class].flights++
+		classes[class].totals = calculateTotals(classes[class].totals, record)
+	}
+
+	var result []classTotals
+	for _, class := range classes {
+		result = append(result, *class)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].class < result[j].class
+	})
+
+	return result
+}
@@ -0,0 +1,69 @@
+package logbook
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestAircraftRegistry(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "logbook.xlsx")
+
+	xls := excelize.NewFile()
+	xls.NewSheet("Aircraft")
+	xls.SetSheetRow("Aircraft", "A1", &[]string{"Registration", "Model", "Engine", "Multi pilot", "Category"})
+	xls.SetSheetRow("Aircraft", "A2", &[]string{"OK-TVS", "B738", "ME", "yes", "Airplane"})
+	xls.SetSheetRow("Aircraft", "A3", &[]string{"OK-LEA", "C152", "SE", "", "Airplane"})
+	assert.Equal(t, xls.SaveAs(fileName), nil)
+
+	logbookConfig := LogbookConfig{
+		SourceType:    "xlsx",
+		FileName:      fileName,
+		AircraftSheet: "Aircraft",
+		AircraftTypes: map[string]AircraftType{"PA34": {Engine: "me", Category: "Airplane"}},
+		Aircraft: []Aircraft{
+			{Registration: "OK-LEA", Model: "C152", AircraftType: AircraftType{Engine: "se", Category: "Airplane"}},
+			{Registration: "OK-SIM", Model: "C172", AircraftType: AircraftType{Engine: "se", Category: "Airplane"}},
+		},
+	}
+
+	registry, err := loadAircraftRegistry(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(registry.aircraft), 3)
+
+	rows := [][]interface{}{
+		{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "", "OK-TVS", "", "03:35", "03:35", "03:35", "", "1"},
+		{"12/06/2021", "LKSZ", "1410", "LKSZ", "1525", "C152", "OK-LEA", "1:15", "", "", "1:15", "3"},
+		{"13/06/2021", "LKSZ", "1410", "LKSZ", "1525", "PA34", "OK-MEA", "", "1:15", "", "1:15", "1"},
+		{"14/06/2021", "LKSZ", "1410", "LKSZ", "1525", "DA42", "OK-DAA", "", "1:15", "", "1:15", "1"},
+		{"06/03/2020", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "01:30"},
+	}
+
+	record := parseRecord(rows[0])
+	aircraft, ok := registry.lookup(record)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, aircraft.className(), "Airplane ME multi pilot")
+
+	registry.fillAircraft(&record)
+	assert.Equal(t, record.aircraft.model, "B738")
+	assert.Equal(t, checkAircraftClass(registry)(record), "")
+
+	record.aircraft.model = "A320"
+	assert.Equal(t, checkAircraftClass(registry)(record), "model is A320, expected B738 for OK-TVS")
+
+	classes := calculateClassTotals(rows, registry)
+	assert.Equal(t, len(classes), 5)
+	assert.Equal(t, classes[0].class, "Airplane ME multi pilot")
+	assert.Equal(t, classes[1].class, "Airplane ME single pilot")
+	assert.Equal(t, classes[2].class, "Airplane SE single pilot")
+	assert.Equal(t, classes[2].totals.time.se.GetTime(), "1:15")
+	assert.Equal(t, classes[2].totals.landings.day, 3)
+	assert.Equal(t, classes[3].class, "Simulator")
+	assert.Equal(t, classes[4].class, "Unknown")
+
+	logbookConfig.SourceType = "db"
+	_, err = loadAircraftRegistry(logbookConfig)
+	assert.Equal(t, err != nil, true)
+}
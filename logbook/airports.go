@@ -0,0 +1,246 @@
+package logbook
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/s2"
+)
+
+// Airport is the airport from the airports database
+type Airport struct {
+	ICAO      string  `json:"icao"`
+	IATA      string  `json:"iata"`
+	Name      string  `json:"name"`
+	City      string  `json:"city"`
+	Region    string  `json:"state"`
+	Country   string  `json:"country"`
+	Elevation int     `json:"elevation"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	TZ        string  `json:"tz"`
+}
+
+// latLng returns the coordinates of the airport
+func (airport Airport) latLng() s2.LatLng {
+	return s2.LatLngFromDegrees(airport.Lat, airport.Lon)
+}
+
+// airportsDB is the airports database indexed by ICAO and IATA codes
+type airportsDB struct {
+	airports map[string]Airport
+	iata     map[string]string
+}
+
+// newAirportsDB returns the airports database with the IATA index
+//
+// airports map[string]Airport - airports by ICAO code
+func newAirportsDB(airports map[string]Airport) airportsDB {
+	db := airportsDB{
+		airports: make(map[string]Airport),
+		iata:     make(map[string]string),
+	}
+
+	for code, airport := range airports {
+		db.add(code, airport)
+	}
+
+	return db
+}
+
+// add adds the airport to the database or replaces the existing one
+func (db airportsDB) add(code string, airport Airport) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if airport.ICAO == "" {
+		airport.ICAO = code
+	}
+	db.airports[code] = airport
+
+	if airport.IATA != "" {
+		db.iata[strings.ToUpper(airport.IATA)] = code
+	}
+}
+
+// lookup returns the airport by ICAO or IATA code
+func (db airportsDB) lookup(code string) (Airport, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if airport, ok := db.airports[code]; ok {
+		return airport, true
+	}
+
+	if icao, ok := db.iata[code]; ok {
+		return db.airports[icao], true
+	}
+
+	return Airport{}, false
+}
+
+// parseAirportsJSON parses the airports database in json format
+func parseAirportsJSON(data []byte) (airportsDB, error) {
+	var airports map[string]Airport
+
+	if err := json.Unmarshal(data, &airports); err != nil {
+		return airportsDB{}, err
+	}
+
+	return newAirportsDB(airports), nil
+}
+
+// loadEmbeddedAirportsDB loads the airports database built in the tool
+func loadEmbeddedAirportsDB() (airportsDB, error) {
+	byteValue, err := content.ReadFile("db/airports.json")
+	if err != nil {
+		return airportsDB{}, err
+	}
+
+	return parseAirportsJSON(byteValue)
+}
+
+// loadBaseAirportsDB loads the database updated with the `airports update` command
+// in case it exists, otherwise the embedded one
+func loadBaseAirportsDB(logbookConfig LogbookConfig) (airportsDB, error) {
+	fileName, err := airportsDBFile(logbookConfig)
+	if err == nil {
+		byteValue, err := os.ReadFile(fileName)
+		if err == nil {
+			return parseAirportsJSON(byteValue)
+		}
+		if !os.IsNotExist(err) {
+			return airportsDB{}, err
+		}
+	}
+
+	return loadEmbeddedAirportsDB()
+}
+
+// loadAirportsDB loads the airports data (location and so on) with the custom airports
+// merged over it
+func loadAirportsDB(logbookConfig LogbookConfig) (airportsDB, error) {
+	db, err := loadBaseAirportsDB(logbookConfig)
+	if err != nil {
+		return db, err
+	}
+
+	if logbookConfig.CustomAirports != "" {
+		custom, err := loadCustomAirports(logbookConfig.CustomAirports)
+		if err != nil {
+			return db, fmt.Errorf("cannot load custom airports: %v", err)
+		}
+
+		for code, airport := range custom {
+			db.add(code, airport)
+		}
+	}
+
+	return db, nil
+}
+
+// loadCustomAirports reads the user airports file. It's either json file in the same format
+// as the airports database or csv file with the header and the columns code, name, lat, lon
+// and optional iata, city, country, elevation and tz
+func loadCustomAirports(fileName string) (map[string]Airport, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	airports := make(map[string]Airport)
+
+	if strings.ToLower(filepath.Ext(fileName)) == ".json" {
+		if err := json.Unmarshal(data, &airports); err != nil {
+			return nil, err
+		}
+		return airports, nil
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff"))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return airports, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"code", "lat", "lon"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is not found", name)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	for i, row := range rows[1:] {
+		code := field(row, "code")
+		if code == "" {
+			continue
+		}
+
+		lat, err1 := strconv.ParseFloat(field(row, "lat"), 64)
+		lon, err2 := strconv.ParseFloat(field(row, "lon"), 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("wrong coordinates for %s in the row %d", code, i+2)
+		}
+
+		elevation, _ := strconv.Atoi(field(row, "elevation"))
+
+		airports[strings.ToUpper(code)] = Airport{
+			ICAO:      strings.ToUpper(code),
+			IATA:      field(row, "iata"),
+			Name:      field(row, "name"),
+			City:      field(row, "city"),
+			Country:   field(row, "country"),
+			Elevation: elevation,
+			Lat:       lat,
+			Lon:       lon,
+			TZ:        field(row, "tz"),
+		}
+	}
+
+	return airports, nil
+}
+
+// LookupAirport prints the airport details by ICAO or IATA code
+func LookupAirport(logbookConfig LogbookConfig, code string) {
+	airports, err := loadAirportsDB(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot load airports database: %v", err)
+	}
+
+	airport, ok := airports.lookup(code)
+	if !ok {
+		log.Fatalf("Cannot find airport %s", code)
+	}
+
+	var location []string
+	for _, part := range []string{airport.City, airport.Region, airport.Country} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+
+	fmt.Printf("ICAO:      %s\n", airport.ICAO)
+	fmt.Printf("IATA:      %s\n", airport.IATA)
+	fmt.Printf("Name:      %s\n", airport.Name)
+	fmt.Printf("City:      %s\n", strings.Join(location, ", "))
+	fmt.Printf("Location:  %.4f, %.4f\n", airport.Lat, airport.Lon)
+	fmt.Printf("Elevation: %d ft\n", airport.Elevation)
+	fmt.Printf("Timezone:  %s\n", airport.TZ)
+}
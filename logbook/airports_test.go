@@ -0,0 +1,80 @@
+package logbook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestAirportsDB(t *testing.T) {
+	airports, err := parseAirportsJSON([]byte(`{
+		"EDDF": {"icao": "EDDF", "iata": "FRA", "name": "Frankfurt am Main Airport", "city": "Frankfurt-am-Main",
+			"state": "Hesse", "country": "DE", "elevation": 364, "lat": 50.0333333, "lon": 8.5705556, "tz": "Europe/Berlin"},
+		"LKSZ": {"name": "Sazena Airport", "country": "CZ", "elevation": 761, "lat": 50.3247, "lon": 14.2589}
+	}`))
+	assert.Equal(t, err, nil)
+
+	airport, ok := airports.lookup("EDDF")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.Region, "Hesse")
+	assert.Equal(t, airport.Elevation, 364)
+	assert.Equal(t, airport.TZ, "Europe/Berlin")
+
+	airport, ok = airports.lookup("fra")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.ICAO, "EDDF")
+
+	// the code is used as ICAO in case it's not set
+	airport, ok = airports.lookup("LKSZ")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.ICAO, "LKSZ")
+
+	_, ok = airports.lookup("XXXX")
+	assert.Equal(t, ok, false)
+
+	// embedded database
+	_, err = loadEmbeddedAirportsDB()
+	assert.Equal(t, err, nil)
+}
+
+func TestCustomAirports(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "custom.csv")
+	os.WriteFile(csvFile, []byte("code,name,lat,lon,elevation\nXHEL,Hospital Heliport,50.0755,14.4378,820\nEDDF,Frankfurt Main,50.0,8.5,\n"), 0644)
+
+	logbookConfig := LogbookConfig{
+		AirportsDB:     filepath.Join(dir, "airports.json"),
+		CustomAirports: csvFile,
+	}
+
+	airports, err := loadAirportsDB(logbookConfig)
+	assert.Equal(t, err, nil)
+
+	airport, ok := airports.lookup("xhel")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.Name, "Hospital Heliport")
+	assert.Equal(t, airport.Elevation, 820)
+
+	// the custom airport replaces the built-in one
+	airport, ok = airports.lookup("EDDF")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.Name, "Frankfurt Main")
+
+	jsonFile := filepath.Join(dir, "custom.json")
+	os.WriteFile(jsonFile, []byte(`{"LKSTRIP": {"name": "Private Strip", "lat": 49.5, "lon": 15.1}}`), 0644)
+	logbookConfig.CustomAirports = jsonFile
+
+	airports, err = loadAirportsDB(logbookConfig)
+	assert.Equal(t, err, nil)
+	airport, ok = airports.lookup("LKSTRIP")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.ICAO, "LKSTRIP")
+
+	os.WriteFile(csvFile, []byte("code,name,lat,lon\nXHEL,Hospital Heliport,north,14.4378\n"), 0644)
+	logbookConfig.CustomAirports = csvFile
+	_, err = loadAirportsDB(logbookConfig)
+	assert.Equal(t, err != nil, true)
+}
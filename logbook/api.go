@@ -0,0 +1,564 @@
+package logbook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// date format of the api parameters and responses
+var apiDateLayout = "2006-01-02"
+
+// default and maximum number of the records in one api response
+var apiDefaultLimit = 100
+var apiMaxLimit = 1000
+
+// name of the cookie with the token of the web ui
+var tokenCookie = "logbook_token"
+
+// apiPlace is the departure or arrival of the flight
+type apiPlace struct {
+	Place string `json:"place"`
+	Time  string `json:"time"`
+}
+
+// apiTimes is the flight times in minutes
+type apiTimes struct {
+	SE         int `json:"se"`
+	ME         int `json:"me"`
+	MCC        int `json:"mcc"`
+	Total      int `json:"total"`
+	Night      int `json:"night"`
+	IFR        int `json:"ifr"`
+	PIC        int `json:"pic"`
+	CoPilot    int `json:"copilot"`
+	Dual       int `json:"dual"`
+	Instructor int `json:"instructor"`
+}
+
+// apiRecord is the logbook record in the api responses
+type apiRecord struct {
+	Date      string   `json:"date"`
+	Departure apiPlace `json:"departure"`
+	Arrival   apiPlace `json:"arrival"`
+	Aircraft  struct {
+		Model string `json:"model"`
+		Reg   string `json:"reg"`
+	} `json:"aircraft"`
+	Time     apiTimes `json:"time"`
+	Landings struct {
+		Day   int `json:"day"`
+		Night int `json:"night"`
+	} `json:"landings"`
+	Simulator struct {
+		Type string `json:"type"`
+		Time int    `json:"time"`
+	} `json:"simulator"`
+	PICName string `json:"pic_name"`
+	Remarks string `json:"remarks"`
+}
+
+// apiTotals is the totals of the period in the api responses
+type apiTotals struct {
+	Period   string   `json:"period"`
+	Flights  int      `json:"flights"`
+	Time     apiTimes `json:"time"`
+	Landings struct {
+		Day   int `json:"day"`
+		Night int `json:"night"`
+	} `json:"landings"`
+	SimulatorTime int `json:"simulator_time"`
+}
+
+// apiCurrency is the currency status in the api responses
+type apiCurrency struct {
+	Requirement string `json:"requirement"`
+	Aircraft    string `json:"aircraft"`
+	Days        int    `json:"days"`
+	Required    int    `json:"required"`
+	Landings    int    `json:"landings"`
+	Current     bool   `json:"current"`
+	ValidUntil  string `json:"valid_until,omitempty"`
+}
+
+// newAPITimes returns the times of the record or the totals in minutes
+func newAPITimes(t times) apiTimes {
+	return apiTimes{
+		SE:         minutes(t.se),
+		ME:         minutes(t.me),
+		MCC:        minutes(t.mcc),
+		Total:      minutes(t.total),
+		Night:      minutes(t.night),
+		IFR:        minutes(t.ifr),
+		PIC:        minutes(t.pic),
+		CoPilot:    minutes(t.copilot),
+		Dual:       minutes(t.dual),
+		Instructor: minutes(t.instructor),
+	}
+}
+
+// newAPIRecord returns the logbook record for the api, the date is converted to the api
+// format in case it's valid
+func newAPIRecord(record logbookRecord) apiRecord {
+	r := apiRecord{
+		Date:      record.date,
+		Departure: apiPlace{Place: record.departure.place, Time: record.departure.time},
+		Arrival:   apiPlace{Place: record.arrival.place, Time: record.arrival.time},
+		Time:      newAPITimes(record.time),
+		PICName:   record.pic,
+		Remarks:   record.remarks,
+	}
+
+	if date, err := time.Parse(dateLayout, record.date); err == nil {
+		r.Date = date.Format(apiDateLayout)
+	}
+
+	r.Aircraft.Model = record.aircraft.model
+	r.Aircraft.Reg = record.aircraft.reg
+	r.Landings.Day = record.landings.day
+	r.Landings.Night = record.landings.night
+	r.Simulator.Type = record.sim.name
+	r.Simulator.Time = minutes(record.sim.time)
+
+	return r
+}
+
+// apiRecordFilter selects the records for the api responses
+type apiRecordFilter struct {
+	from     time.Time
+	to       time.Time
+	aircraft string
+	reg      string
+	airport  string
+}
+
+// parseAPIDate parses the date parameter, the zero time is returned for the empty one
+func parseAPIDate(value string, name string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(apiDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("wrong %s parameter %s, should be YYYY-MM-DD", name, value)
+	}
+
+	return date, nil
+}
+
+// newAPIRecordFilter returns the filter from the query parameters
+func newAPIRecordFilter(r *http.Request) (apiRecordFilter, error) {
+	query := r.URL.Query()
+
+	var filter apiRecordFilter
+	var err error
+
+	filter.from, err = parseAPIDate(query.Get("from"), "from")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.to, err = parseAPIDate(query.Get("to"), "to")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.aircraft = query.Get("aircraft")
+	filter.reg = query.Get("reg")
+	filter.airport = query.Get("airport")
+
+	return filter, nil
+}
+
+// match returns if the record matches the filter, the records without a valid date don't
+// match the date filters
+func (f apiRecordFilter) match(record logbookRecord, date time.Time, dateErr error) bool {
+	if !f.from.IsZero() || !f.to.IsZero() {
+		if dateErr != nil {
+			return false
+		}
+		if !f.from.IsZero() && date.Before(f.from) {
+			return false
+		}
+		if !f.to.IsZero() && date.After(f.to) {
+			return false
+		}
+	}
+
+	if f.aircraft != "" && !strings.EqualFold(record.aircraft.model, f.aircraft) {
+		return false
+	}
+
+	if f.reg != "" && !strings.EqualFold(record.aircraft.reg, f.reg) {
+		return false
+	}
+
+	if f.airport != "" && !strings.EqualFold(record.departure.place, f.airport) && !strings.EqualFold(record.arrival.place, f.airport) {
+		return false
+	}
+
+	return true
+}
+
+// datedRecord is the logbook record with the parsed date
+type datedRecord struct {
+	record  logbookRecord
+	date    time.Time
+	dateErr error
+}
+
+// filterRecords returns the matching records in the chronological order, the records without
+// a valid date are at the end
+func filterRecords(rows [][]interface{}, filter apiRecordFilter) []datedRecord {
+	var records []datedRecord
+	for _, row := range rows {
+		record := parseRecord(row)
+		if record.date == "" {
+			continue
+		}
+
+		date, err := time.Parse(dateLayout, record.date)
+		if !filter.match(record, date, err) {
+			continue
+		}
+
+		records = append(records, datedRecord{record: record, date: date, dateErr: err})
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if (records[i].dateErr == nil) != (records[j].dateErr == nil) {
+			return records[i].dateErr == nil
+		}
+		if !records[i].date.Equal(records[j].date) {
+			return records[i].date.Before(records[j].date)
+		}
+		return records[i].record.departure.time < records[j].record.departure.time
+	})
+
+	return records
+}
+
+// calculatePeriodTotals returns the totals of the records per period in the order of the
+// records, the records without a valid date are skipped in case the period layout is set
+//
+// records []datedRecord - filtered records
+//
+// layout string - date layout of the period, e.g. 2006-01 for months, or empty for all records
+func calculatePeriodTotals(records []datedRecord, layout string) []apiTotals {
+	var periods []string
+	totals := make(map[string]logbookTotalRecord)
+	flights := make(map[string]int)
+
+	for _, d := range records {
+		period := "all"
+		if layout != "" {
+			if d.dateErr != nil {
+				continue
+			}
+			period = d.date.Format(layout)
+		}
+
+		if _, ok := totals[period]; !ok {
+			periods = append(periods, period)
+		}
+		totals[period] = calculateTotals(totals[period], d.record)
+		flights[period]++
+	}
+
+	response := []apiTotals{}
+	for _, period := range periods {
+		total := totals[period]
+
+		t := apiTotals{
+			Period:        period,
+			Flights:       flights[period],
+			Time:          newAPITimes(total.time),
+			SimulatorTime: minutes(total.sim.time),
+		}
+		t.Landings.Day = total.landings.day
+		t.Landings.Night = total.landings.night
+
+		response = append(response, t)
+	}
+
+	return response
+}
+
+// newAPICurrency returns the currency statuses for the api
+func newAPICurrency(statuses []currencyStatus) []apiCurrency {
+	currency := []apiCurrency{}
+	for _, status := range statuses {
+		c := apiCurrency{
+			Requirement: status.rule.name,
+			Aircraft:    status.aircraft,
+			Days:        status.rule.days,
+			Required:    status.rule.landings,
+			Landings:    status.landings,
+			Current:     status.current,
+		}
+		if !status.validUntil.IsZero() {
+			c.ValidUntil = status.validUntil.Format(apiDateLayout)
+		}
+
+		currency = append(currency, c)
+	}
+
+	return currency
+}
+
+// writeJSON writes the api response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+// writeAPIError writes the error response
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// apiRows returns the loaded records, the api returns the error instead of the stale records
+// in case the logbook cannot be reloaded
+func (s *logbookServer) apiRows() ([][]interface{}, error) {
+	s.refresh()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, fmt.Errorf("cannot load logbook: %v", s.err)
+	}
+
+	return s.rows, nil
+}
+
+// apiHandler returns the handler of the api, the tokens are checked for all pages by
+// requireToken
+func (s *logbookServer) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/records", s.handleAPIRecords)
+	mux.HandleFunc("/api/totals", s.handleAPITotals)
+	mux.HandleFunc("/api/currency", s.handleAPICurrency)
+	mux.HandleFunc("/api/logbook.pdf", s.handlePDF)
+	mux.HandleFunc("/api/map.png", s.handleMap)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// requireToken returns the handler which checks the api tokens for all pages in case there are
+// tokens in the config. The browser opens the web ui once with the ?token= parameter, the token
+// is stored in the cookie and the page is reloaded without the parameter
+func (s *logbookServer) requireToken(next http.Handler) http.Handler {
+	if len(s.config.APITokens) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the api description is public
+		if r.URL.Path == "/api/openapi.json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		if token := query.Get("token"); token != "" && s.validToken(token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			query.Del("token")
+			u := *r.URL
+			u.RawQuery = query.Encode()
+			http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		if !s.authorized(r) {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="logbook"`)
+				writeAPIError(w, http.StatusUnauthorized, fmt.Errorf("missing or wrong api token"))
+			} else {
+				http.Error(w, "Missing or wrong token, open the logbook with ?token= and one of the api_tokens", http.StatusUnauthorized)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized returns if the request has one of the api tokens in the Authorization header with
+// the Bearer scheme or in the cookie of the web ui
+func (s *logbookServer) authorized(r *http.Request) bool {
+	if header := r.Header.Get("Authorization"); header != "" {
+		return strings.HasPrefix(header, "Bearer ") && s.validToken(strings.TrimPrefix(header, "Bearer "))
+	}
+
+	if cookie, err := r.Cookie(tokenCookie); err == nil {
+		return s.validToken(cookie.Value)
+	}
+
+	return false
+}
+
+// validToken returns if the token is one of the api tokens from the config
+func (s *logbookServer) validToken(token string) bool {
+	valid := false
+	for _, apiToken := range s.config.APITokens {
+		if token != "" && apiToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(apiToken)) == 1 {
+			valid = true
+		}
+	}
+
+	return valid
+}
+
+// handleOpenAPI returns the api description
+func (s *logbookServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	data, err := webContent.ReadFile("web/openapi.json")
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// handleAPIRecords returns the filtered records in the chronological or reverse order
+func (s *logbookServer) handleAPIRecords(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.apiRows()
+	if err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	filter, err := newAPIRecordFilter(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := apiDefaultLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > apiMaxLimit {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("wrong limit %s, should be from 1 to %d", value, apiMaxLimit))
+			return
+		}
+	}
+
+	offset := 0
+	if value := query.Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("wrong offset %s", value))
+			return
+		}
+	}
+
+	records := filterRecords(rows, filter)
+
+	switch order := query.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	default:
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("wrong order %s, should be asc or desc", order))
+		return
+	}
+
+	response := struct {
+		Total   int         `json:"total"`
+		Offset  int         `json:"offset"`
+		Limit   int         `json:"limit"`
+		Records []apiRecord `json:"records"`
+	}{Total: len(records), Offset: offset, Limit: limit, Records: []apiRecord{}}
+
+	for i := offset; i < len(records) && i < offset+limit; i++ {
+		response.Records = append(response.Records, newAPIRecord(records[i].record))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleAPITotals returns the totals of the filtered records per month, year or for all
+// records together
+func (s *logbookServer) handleAPITotals(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.apiRows()
+	if err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	filter, err := newAPIRecordFilter(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var layout string
+	switch period := r.URL.Query().Get("period"); period {
+	case "", "all":
+	case "month":
+		layout = "2006-01"
+	case "year":
+		layout = "2006"
+	default:
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("wrong period %s, should be all, month or year", period))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, calculatePeriodTotals(filterRecords(rows, filter), layout))
+}
+
+// handleAPICurrency returns the recent experience status for every aircraft type
+func (s *logbookServer) handleAPICurrency(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.apiRows()
+	if err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	today := s.now()
+	if value := r.URL.Query().Get("date"); value != "" {
+		today, err = parseAPIDate(value, "date")
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	response := struct {
+		Date     string        `json:"date"`
+		Currency []apiCurrency `json:"currency"`
+	}{Date: today.Format(apiDateLayout), Currency: newAPICurrency(calculateCurrency(rows, today))}
+
+	writeJSON(w, http.StatusOK, response)
+}
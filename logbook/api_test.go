@@ -0,0 +1,159 @@
+package logbook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// apiGet returns the decoded response of the test api
+func apiGet(t *testing.T, handler http.Handler, url string, token string, value interface{}) int {
+	r := httptest.NewRequest(http.MethodGet, url, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if value != nil {
+		assert.Equal(t, w.Header().Get("Content-Type"), "application/json")
+		assert.Equal(t, json.Unmarshal(w.Body.Bytes(), value), nil)
+	}
+
+	return w.Code
+}
+
+func TestAPI(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "logbook.csv")
+	assert.Equal(t, os.WriteFile(csvFile, []byte(testServerCSV), 0644), nil)
+
+	customAirports := filepath.Join(dir, "custom.csv")
+	os.WriteFile(customAirports, []byte("code,name,lat,lon\nLEMG,Malaga,36.6749,-4.49911\nLKPR,Prague,50.1008,14.26\nLKPD,Pardubice,50.0134,15.7386\n"), 0644)
+
+	server, err := newLogbookServer(LogbookConfig{
+		SourceType:     "csv",
+		FileName:       csvFile,
+		StartRow:       1,
+		AirportsDB:     filepath.Join(dir, "airports.json"),
+		CustomAirports: customAirports,
+		MBTilesFile:    emptyMBTiles(t),
+		MapWidth:       400,
+		MapHeight:      300,
+		APITokens:      []string{"secret"},
+	})
+	assert.Equal(t, err, nil)
+	server.now = func() time.Time { return time.Date(2021, 10, 12, 10, 0, 0, 0, time.UTC) }
+	handler := server.handler()
+
+	// the token is required except the api description
+	var apiError struct {
+		Error string `json:"error"`
+	}
+	assert.Equal(t, apiGet(t, handler, "/api/records", "", &apiError), http.StatusUnauthorized)
+	assert.Equal(t, apiError.Error, "missing or wrong api token")
+	assert.Equal(t, apiGet(t, handler, "/api/records", "wrong", nil), http.StatusUnauthorized)
+	assert.Equal(t, apiGet(t, handler, "/api/logbook.pdf", "", nil), http.StatusUnauthorized)
+
+	// the scheme is required
+	r := httptest.NewRequest(http.MethodGet, "/api/records", nil)
+	r.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusUnauthorized)
+
+	// the web ui requires the token as well
+	for _, url := range []string{"/", "/flights", "/stats", "/logbook.pdf", "/map.png", "/version", "/flights?token=wrong"} {
+		assert.Equal(t, serverGet(t, handler, url).Code, http.StatusUnauthorized)
+	}
+
+	// the token from the link is kept in the cookie
+	w = serverGet(t, handler, "/flights?page=1&token=secret")
+	assert.Equal(t, w.Code, http.StatusSeeOther)
+	assert.Equal(t, w.Header().Get("Location"), "/flights?page=1")
+
+	cookies := w.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].HttpOnly, true)
+
+	for _, url := range []string{"/flights", "/logbook.pdf"} {
+		r = httptest.NewRequest(http.MethodGet, url, nil)
+		r.AddCookie(cookies[0])
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, w.Code, http.StatusOK)
+	}
+
+	var openapi map[string]interface{}
+	assert.Equal(t, apiGet(t, handler, "/api/openapi.json", "", &openapi), http.StatusOK)
+	assert.Equal(t, openapi["openapi"], "3.0.3")
+
+	var records struct {
+		Total   int         `json:"total"`
+		Records []apiRecord `json:"records"`
+	}
+	assert.Equal(t, apiGet(t, handler, "/api/records", "secret", &records), http.StatusOK)
+	assert.Equal(t, records.Total, 3)
+	assert.Equal(t, records.Records[0].Date, "2021-10-08")
+	assert.Equal(t, records.Records[0].Time.Total, 215)
+	assert.Equal(t, records.Records[0].Landings.Night, 1)
+
+	apiGet(t, handler, "/api/records?airport=lkpd&order=desc&limit=1", "secret", &records)
+	assert.Equal(t, records.Total, 1)
+	assert.Equal(t, records.Records[0].Aircraft.Reg, "OK-LEA")
+
+	apiGet(t, handler, "/api/records?from=2021-10-09&to=2021-10-10&aircraft=b738", "secret", &records)
+	assert.Equal(t, records.Total, 1)
+	assert.Equal(t, records.Records[0].Arrival.Place, "LEMG")
+
+	apiGet(t, handler, "/api/records?offset=5", "secret", &records)
+	assert.Equal(t, records.Total, 3)
+	assert.Equal(t, len(records.Records), 0)
+
+	assert.Equal(t, apiGet(t, handler, "/api/records?from=10/10/2021", "secret", &apiError), http.StatusBadRequest)
+	assert.Equal(t, apiError.Error, "wrong from parameter 10/10/2021, should be YYYY-MM-DD")
+	assert.Equal(t, apiGet(t, handler, "/api/records?limit=0", "secret", nil), http.StatusBadRequest)
+
+	var totals []apiTotals
+	assert.Equal(t, apiGet(t, handler, "/api/totals?period=month", "secret", &totals), http.StatusOK)
+	assert.Equal(t, len(totals), 1)
+	assert.Equal(t, totals[0].Period, "2021-10")
+	assert.Equal(t, totals[0].Flights, 3)
+	assert.Equal(t, totals[0].Time.Total, 485)
+	assert.Equal(t, totals[0].Landings.Day, 2)
+
+	apiGet(t, handler, "/api/totals?aircraft=C152", "secret", &totals)
+	assert.Equal(t, totals[0].Period, "all")
+	assert.Equal(t, totals[0].Time.SE, 60)
+	assert.Equal(t, apiGet(t, handler, "/api/totals?period=week", "secret", nil), http.StatusBadRequest)
+
+	var currency struct {
+		Date     string        `json:"date"`
+		Currency []apiCurrency `json:"currency"`
+	}
+	assert.Equal(t, apiGet(t, handler, "/api/currency", "secret", &currency), http.StatusOK)
+	assert.Equal(t, currency.Date, "2021-10-12")
+	assert.Equal(t, len(currency.Currency), 4)
+	assert.Equal(t, currency.Currency[0].Aircraft, "B738")
+	assert.Equal(t, currency.Currency[0].Landings, 2)
+	assert.Equal(t, currency.Currency[0].Current, false)
+	assert.Equal(t, currency.Currency[0].ValidUntil, "")
+
+	assert.Equal(t, apiGet(t, handler, "/api/currency?date=12/10/2021", "secret", &apiError), http.StatusBadRequest)
+	assert.Equal(t, apiError.Error, "wrong date parameter 12/10/2021, should be YYYY-MM-DD")
+
+	assert.Equal(t, apiGet(t, handler, "/api/logbook.pdf", "secret", nil), http.StatusOK)
+	assert.Equal(t, apiGet(t, handler, "/api/map.png", "secret", nil), http.StatusOK)
+
+	// the api doesn't return the stale records
+	os.Remove(csvFile)
+	assert.Equal(t, apiGet(t, handler, "/api/records", "secret", &apiError), http.StatusServiceUnavailable)
+}
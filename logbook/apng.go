@@ -0,0 +1,182 @@
+package logbook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+// pngSignature is the first bytes of the png file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngChunk is the chunk of the png file
+type pngChunk struct {
+	name string
+	data []byte
+}
+
+// readPNGChunks returns the chunks of the encoded png image
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("not a png image")
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []pngChunk
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data[:4]))
+		if len(data) < 12+length {
+			return nil, fmt.Errorf("truncated png chunk")
+		}
+
+		chunks = append(chunks, pngChunk{name: string(data[4:8]), data: data[8 : 8+length]})
+		data = data[12+length:]
+	}
+
+	return chunks, nil
+}
+
+// writePNGChunk writes the chunk with its length and checksum
+func writePNGChunk(w io.Writer, name string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], name)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apngFrame is the encoded frame of the animated png
+type apngFrame struct {
+	width  int
+	height int
+	delay  int
+	chunks []pngChunk
+}
+
+// apngEncoder collects the frames of the animated png, the frames are kept compressed
+type apngEncoder struct {
+	frames []apngFrame
+}
+
+// addFrame encodes the frame, the delay is in milliseconds. The frames should have the same
+// size and colour type
+func (e *apngEncoder) addFrame(img image.Image, delay int) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if len(e.frames) > 0 && !bytes.Equal(chunks[0].data, e.frames[0].chunks[0].data) {
+		return fmt.Errorf("frame %d has different size or colour type", len(e.frames)+1)
+	}
+
+	e.frames = append(e.frames, apngFrame{
+		width:  img.Bounds().Dx(),
+		height: img.Bounds().Dy(),
+		delay:  delay,
+		chunks: chunks,
+	})
+
+	return nil
+}
+
+// encode writes the animated png, which is played in a loop. The first frame is shown by
+// the viewers without animation support
+func (e *apngEncoder) encode(w io.Writer) error {
+	if len(e.frames) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+
+	sequence := uint32(0)
+
+	for i, frame := range e.frames {
+		frameWritten := false
+
+		for _, chunk := range frame.chunks {
+			var err error
+
+			switch {
+			case chunk.name == "IHDR" && i == 0:
+				if err := writePNGChunk(w, "IHDR", chunk.data); err != nil {
+					return err
+				}
+
+				// the number of plays is 0 for the infinite loop
+				animationControl := make([]byte, 8)
+				binary.BigEndian.PutUint32(animationControl, uint32(len(e.frames)))
+				err = writePNGChunk(w, "acTL", animationControl)
+
+			case chunk.name == "IDAT":
+				if !frameWritten {
+					// the frame offset is 0, 0, dispose and blend operations are "none" and "source"
+					frameControl := make([]byte, 26)
+					binary.BigEndian.PutUint32(frameControl[0:], sequence)
+					binary.BigEndian.PutUint32(frameControl[4:], uint32(frame.width))
+					binary.BigEndian.PutUint32(frameControl[8:], uint32(frame.height))
+					// the delay is in 1/100 of second as for gif, the longer delays are clamped
+					delay := frame.delay / 10
+					if delay > math.MaxUint16 {
+						delay = math.MaxUint16
+					}
+					binary.BigEndian.PutUint16(frameControl[20:], uint16(delay))
+					binary.BigEndian.PutUint16(frameControl[22:], 100)
+					sequence++
+
+					if err := writePNGChunk(w, "fcTL", frameControl); err != nil {
+						return err
+					}
+					frameWritten = true
+				}
+
+				if i == 0 {
+					err = writePNGChunk(w, "IDAT", chunk.data)
+				} else {
+					data := make([]byte, 4+len(chunk.data))
+					binary.BigEndian.PutUint32(data, sequence)
+					copy(data[4:], chunk.data)
+					sequence++
+					err = writePNGChunk(w, "fdAT", data)
+				}
+
+			case chunk.name == "IHDR" || chunk.name == "IEND":
+
+			case i == 0:
+				// the palette and other chunks of the first frame are shared by all frames
+				err = writePNGChunk(w, chunk.name, chunk.data)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return writePNGChunk(w, "IEND", nil)
+}
@@ -0,0 +1,68 @@
+package logbook
+
+import (
+	"fmt"
+	"time"
+)
+
+// blockTime returns the time between departure and arrival of the flight
+func blockTime(record logbookRecord) (time.Duration, error) {
+	departure, arrival, err := flightTimes(record)
+	if err != nil {
+		return 0, err
+	}
+
+	return arrival.Sub(departure), nil
+}
+
+// checkBlockTime returns the check which compares the total time of the flight with
+// the time between departure and arrival
+//
+// tolerance time.Duration - allowed difference between the logbook and calculated times
+func checkBlockTime(tolerance time.Duration) recordCheck {
+	return func(record logbookRecord) string {
+		if !hasFlightTimes(record) {
+			return ""
+		}
+
+		block, err := blockTime(record)
+		if err != nil {
+			return fmt.Sprintf("cannot calculate block time: %v", err)
+		}
+
+		if diff := block - record.time.total.time; diff > tolerance || diff < -tolerance {
+			calculated := logbookTime{time: block}
+			return fmt.Sprintf("total time is %s, block time %s", record.time.total.GetTime(true), calculated.GetTime(true))
+		}
+
+		return ""
+	}
+}
+
+// fillFlightTimes sets the total time from the departure and arrival times in case it's not set
+// and then SE, ME or MCC time according to the aircraft class if none of them is set
+//
+// record *logbookRecord - logbook record
+//
+// registry aircraftRegistry - aircraft registry
+func fillFlightTimes(record *logbookRecord, registry aircraftRegistry) {
+	if record.departure.place == "" && record.departure.time == "" {
+		return
+	}
+
+	registry.fillAircraft(record)
+
+	if record.time.total.time == 0 {
+		if block, err := blockTime(*record); err == nil {
+			record.time.total.time = block
+		}
+	}
+
+	if record.time.se.time != 0 || record.time.me.time != 0 || record.time.mcc.time != 0 {
+		return
+	}
+
+	if aircraft, ok := registry.lookup(*record); ok {
+		record.time.se.time, record.time.me.time, record.time.mcc.time = classTimes(aircraft.AircraftType, record.time.total.time)
+	}
+}
@@ -0,0 +1,73 @@
+package logbook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+var testRegistry = aircraftRegistry{types: map[string]AircraftType{
+	"B738": {Engine: "me", MultiPilot: true},
+	"C152": {Engine: "se"},
+	"PA34": {Engine: "me"},
+}}
+
+func TestBlockTime(t *testing.T) {
+	record := parseRecord([]interface{}{"31/12/2021", "LEMG", "2330", "LKPR", "0205", "B738", "OK-TVS", "", "", "", "2:35"})
+
+	block, err := blockTime(record)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, block, 155*time.Minute)
+	assert.Equal(t, checkBlockTime(5*time.Minute)(record), "")
+
+	record.time.total.SetTime("2:00")
+	assert.Equal(t, checkBlockTime(5*time.Minute)(record), "total time is 2:00, block time 2:35")
+
+	record.arrival.time = "25:00"
+	assert.Equal(t, checkBlockTime(5*time.Minute)(record) != "", true)
+
+	// simulator sessions and the records without times are skipped
+	record = parseRecord([]interface{}{"06/03/2020", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "01:30"})
+	assert.Equal(t, checkBlockTime(5*time.Minute)(record), "")
+
+	record = parseRecord([]interface{}{"06/03/2020", "LKPR", "1000", "LKPR", "1130", "", "", "", "", "", "01:30", "", "", "", "", "", "", "", "", "ULT-28", "01:30"})
+	assert.Equal(t, checkBlockTime(5*time.Minute)(record), "")
+
+	record = parseRecord([]interface{}{"08/10/2021", "LEMG", "", "LKPR", "", "B738", "OK-TVS", "", "", "", "2:35"})
+	assert.Equal(t, checkBlockTime(5*time.Minute)(record), "")
+
+	// only one of the times is set
+	record = parseRecord([]interface{}{"08/10/2021", "LEMG", "1930", "LKPR", "", "B738", "OK-TVS", "", "", "", "2:35"})
+	assert.Equal(t, checkBlockTime(5*time.Minute)(record), "cannot calculate block time: departure or arrival time is not set")
+}
+
+func TestAircraftClass(t *testing.T) {
+	record := parseRecord([]interface{}{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35"})
+	assert.Equal(t, checkAircraftClass(testRegistry)(record), "")
+
+	record.aircraft.model = "PA34"
+	assert.Equal(t, checkAircraftClass(testRegistry)(record), "ME time is 0:00, expected 3:35, MCC time is 3:35, expected 0:00 for PA34")
+
+	record.aircraft.model = "A320"
+	assert.Equal(t, checkAircraftClass(testRegistry)(record), "")
+}
+
+func TestFillFlightTimes(t *testing.T) {
+	record := parseRecord([]interface{}{"12/06/2021", "LKSZ", "1410", "LKSZ", "1525", "C152", "OK-LEA"})
+
+	fillFlightTimes(&record, testRegistry)
+	assert.Equal(t, record.time.total.GetTime(), "1:15")
+	assert.Equal(t, record.time.se.GetTime(), "1:15")
+	assert.Equal(t, record.time.me.GetTime(), "")
+
+	// the set times are not changed
+	record = parseRecord([]interface{}{"12/06/2021", "LKSZ", "1410", "LKSZ", "1525", "C152", "OK-LEA", "1:00", "", "", "1:00"})
+	fillFlightTimes(&record, testRegistry)
+	assert.Equal(t, record.time.total.GetTime(), "1:00")
+	assert.Equal(t, record.time.se.GetTime(), "1:00")
+
+	record = parseRecord([]interface{}{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS"})
+	fillFlightTimes(&record, testRegistry)
+	assert.Equal(t, record.time.mcc.GetTime(), "3:35")
+}
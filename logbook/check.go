@@ -0,0 +1,91 @@
+package logbook
+
+import (
+	"fmt"
+	"log"
+)
+
+// recordCheck validates the logbook record and returns the description of the issue
+// or an empty string if the record is fine
+type recordCheck func(record logbookRecord) string
+
+// checkIssue is the issue found in the logbook record
+type checkIssue struct {
+	row     int
+	record  logbookRecord
+	message string
+}
+
+func (issue checkIssue) String() string {
+	route := issue.record.sim.name
+	if issue.record.departure.place != "" {
+		route = fmt.Sprintf("%s-%s", issue.record.departure.place, issue.record.arrival.place)
+	}
+
+	if issue.row > 0 {
+		return fmt.Sprintf("row %d, %s %s: %s", issue.row, issue.record.date, route, issue.message)
+	}
+
+	return fmt.Sprintf("%s %s: %s", issue.record.date, route, issue.message)
+}
+
+// checkRecords runs the checks against the logbook records. The row numbers are reported
+// only for the single spreadsheet source, so they can be used for the edit command
+//
+// logbookConfig LogbookConfig - logbook configuration
+//
+// rows [][]interface{} - logbook dump
+//
+// checks []recordCheck - list of the checks
+func checkRecords(logbookConfig LogbookConfig, rows [][]interface{}, checks []recordCheck) []checkIssue {
+	var issues []checkIssue
+
+	withRows := len(logbookConfig.Sources) == 0 && logbookConfig.SourceType != "db"
+
+	for i, row := range rows {
+		record := parseRecord(row)
+
+		for _, check := range checks {
+			if message := check(record); message != "" {
+				issue := checkIssue{record: record, message: message}
+				if withRows {
+					issue.row = logbookConfig.StartRow + i
+				}
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	return issues
+}
+
+// Check validates the logbook records and prints the found issues
+func Check(logbookConfig LogbookConfig) {
+	airports, err := loadAirportsDB(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot load airports database: %v", err)
+	}
+
+	registry, err := loadAircraftRegistry(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot load aircraft registry: %v", err)
+	}
+
+	response, err := getLogbookDump(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot get logbook dump: %v", err)
+	}
+
+	checks := []recordCheck{
+		checkBlockTime(logbookConfig.CheckTolerance),
+		checkAircraftClass(registry),
+		checkNightTime(airports, logbookConfig.CheckTolerance),
+	}
+
+	issues := checkRecords(logbookConfig, response, checks)
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+
+	fmt.Printf("%d records have been checked, %d issues found\n", len(response), len(issues))
+}
@@ -0,0 +1,148 @@
+package logbook
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// placeTotals is the number of flights, the total time and the landings in the country or
+// the region of the country
+type placeTotals struct {
+	country    string
+	region     string
+	airports   int
+	regions    int
+	flights    int
+	time       time.Duration
+	landings   int
+	firstVisit time.Time
+}
+
+// collectPlaceTotals returns the totals of the visited countries or regions sorted by the
+// first visit. The flight counts for the departure and the arrival countries, the domestic
+// flight only once, and the landings count for the arrival country. The airports not found
+// in the database or without the country are skipped
+//
+// rows [][]interface{} - logbook rows
+//
+// airports airportsDB - airports database
+//
+// byRegion bool - collect the totals of the regions instead of the countries
+func collectPlaceTotals(rows [][]interface{}, airports airportsDB, byRegion bool) []placeTotals {
+	places := make(map[string]*placeTotals)
+	placeAirports := make(map[string]map[string]struct{})
+	placeRegions := make(map[string]map[string]struct{})
+
+	place := func(code string) *placeTotals {
+		airport, ok := airports.lookup(code)
+		if !ok || airport.Country == "" {
+			return nil
+		}
+
+		key := airport.Country
+		if byRegion {
+			key += "/" + airport.Region
+		}
+
+		p, ok := places[key]
+		if !ok {
+			p = &placeTotals{country: airport.Country}
+			if byRegion {
+				p.region = airport.Region
+			}
+			places[key] = p
+			placeAirports[key] = make(map[string]struct{})
+			placeRegions[key] = make(map[string]struct{})
+		}
+
+		placeAirports[key][airport.ICAO] = struct{}{}
+		if airport.Region != "" {
+			placeRegions[key][airport.Region] = struct{}{}
+		}
+
+		return p
+	}
+
+	for _, row := range rows {
+		record := parseRecord(row)
+
+		// the date is optional here, the flights are counted anyway
+		date, _ := time.Parse(dateLayout, record.date)
+
+		departure := place(record.departure.place)
+		arrival := place(record.arrival.place)
+
+		for i, p := range []*placeTotals{departure, arrival} {
+			if p == nil || (i == 1 && arrival == departure) {
+				continue
+			}
+
+			p.flights++
+			p.time += record.time.total.time
+
+			if !date.IsZero() && (p.firstVisit.IsZero() || date.Before(p.firstVisit)) {
+				p.firstVisit = date
+			}
+		}
+
+		if arrival != nil {
+			arrival.landings += record.landings.day + record.landings.night
+		}
+	}
+
+	var result []placeTotals
+	for key, p := range places {
+		p.airports = len(placeAirports[key])
+		p.regions = len(placeRegions[key])
+		result = append(result, *p)
+	}
+
+	// the places without dates are at the end
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].firstVisit.Equal(result[j].firstVisit) {
+			if result[i].firstVisit.IsZero() || result[j].firstVisit.IsZero() {
+				return result[j].firstVisit.IsZero()
+			}
+			return result[i].firstVisit.Before(result[j].firstVisit)
+		}
+		if result[i].country != result[j].country {
+			return result[i].country < result[j].country
+		}
+		return result[i].region < result[j].region
+	})
+
+	return result
+}
+
+// printPlaceTotals prints the table with the totals of the countries or the regions
+func printPlaceTotals(w io.Writer, places []placeTotals, byRegion bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if byRegion {
+		fmt.Fprintln(tw, "Country\tRegion\tAirports\tFirst visit\tFlights\tTime\tLandings")
+	} else {
+		fmt.Fprintln(tw, "Country\tRegions\tAirports\tFirst visit\tFlights\tTime\tLandings")
+	}
+
+	for _, p := range places {
+		firstVisit := ""
+		if !p.firstVisit.IsZero() {
+			firstVisit = p.firstVisit.Format(dateLayout)
+		}
+
+		region := fmt.Sprint(p.regions)
+		if byRegion {
+			region = p.region
+		}
+
+		placeTime := logbookTime{time: p.time}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\t%s\t%d\n",
+			strings.ToUpper(p.country), region, p.airports, firstVisit, p.flights, placeTime.GetTime(true), p.landings)
+	}
+
+	tw.Flush()
+}
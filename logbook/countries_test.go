@@ -0,0 +1,52 @@
+package logbook
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+var testCountryAirports = newAirportsDB(map[string]Airport{
+	"LEMG": {IATA: "AGP", Region: "Andalusia", Country: "ES", Lat: 36.6749, Lon: -4.49911},
+	"LKPR": {IATA: "PRG", Region: "Prague", Country: "CZ", Lat: 50.1008, Lon: 14.26},
+	"LKPD": {IATA: "PED", Region: "Pardubice", Country: "CZ", Lat: 50.0134, Lon: 15.7386},
+})
+
+func TestPlaceTotals(t *testing.T) {
+	countries := collectPlaceTotals(testMapRows, testCountryAirports, false)
+	assert.Equal(t, len(countries), 2)
+
+	// the domestic flight is counted once, the landings are counted for the arrival country
+	assert.Equal(t, countries[0], placeTotals{
+		country: "CZ", airports: 2, regions: 2, flights: 4, time: 9*time.Hour + 5*time.Minute, landings: 2,
+		firstVisit: time.Date(2021, 10, 8, 0, 0, 0, 0, time.UTC),
+	})
+	assert.Equal(t, countries[1].country, "ES")
+	assert.Equal(t, countries[1].flights, 2)
+	assert.Equal(t, countries[1].time, 7*time.Hour+5*time.Minute)
+	assert.Equal(t, countries[1].landings, 1)
+
+	regions := collectPlaceTotals(testMapRows, testCountryAirports, true)
+	assert.Equal(t, len(regions), 3)
+	assert.Equal(t, regions[0].region, "Prague")
+	assert.Equal(t, regions[0].flights, 3)
+	assert.Equal(t, regions[1].region, "Andalusia")
+	assert.Equal(t, regions[2].region, "Pardubice")
+	assert.Equal(t, regions[2].firstVisit, time.Date(2021, 10, 11, 0, 0, 0, 0, time.UTC))
+
+	// the airports without the country are skipped
+	assert.Equal(t, len(collectPlaceTotals(testMapRows, testAirports, false)), 0)
+
+	var buf bytes.Buffer
+	printPlaceTotals(&buf, countries, false)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, strings.Fields(lines[1]), []string{"CZ", "2", "2", "08/10/2021", "4", "9:05", "2"})
+
+	buf.Reset()
+	printPlaceTotals(&buf, regions, true)
+	assert.Equal(t, strings.Contains(buf.String(), "Andalusia"), true)
+}
@@ -0,0 +1,109 @@
+package logbook
+
+import (
+	"sort"
+	"time"
+)
+
+// currencyRule is the recent experience requirement, the number of landings in the aircraft
+// of the same type during the period
+type currencyRule struct {
+	name     string
+	days     int
+	landings int
+	night    bool
+}
+
+// recent experience requirements for carrying passengers, FCL.060
+var currencyRules = []currencyRule{
+	{name: "landings", days: 90, landings: 3},
+	{name: "night landings", days: 90, landings: 3, night: true},
+}
+
+// currencyStatus is the recent experience of the pilot on the aircraft type
+type currencyStatus struct {
+	rule       currencyRule
+	aircraft   string
+	landings   int
+	current    bool
+	validUntil time.Time // last day of the currency, zero in case there are not enough landings
+}
+
+// calculateCurrency returns the currency status for every aircraft type flown and every rule.
+// The status is valid including the last day before the oldest of the required landings
+// leaves the period, the simulator sessions are not counted
+//
+// rows [][]interface{} - logbook rows
+//
+// today time.Time - date of the status
+func calculateCurrency(rows [][]interface{}, today time.Time) []currencyStatus {
+	type landingDate struct {
+		date     time.Time
+		landings int
+	}
+
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	dayLandings := make(map[string][]landingDate)
+	nightLandings := make(map[string][]landingDate)
+
+	for _, row := range rows {
+		record := parseRecord(row)
+		if record.sim.name != "" || record.aircraft.model == "" {
+			continue
+		}
+
+		date, err := time.Parse(dateLayout, record.date)
+		if err != nil || date.After(today) {
+			continue
+		}
+
+		model := record.aircraft.model
+		dayLandings[model] = append(dayLandings[model], landingDate{date: date, landings: record.landings.day + record.landings.night})
+		nightLandings[model] = append(nightLandings[model], landingDate{date: date, landings: record.landings.night})
+	}
+
+	var models []string
+	for model := range dayLandings {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	var statuses []currencyStatus
+	for _, model := range models {
+		for _, rule := range currencyRules {
+			dates := dayLandings[model]
+			if rule.night {
+				dates = nightLandings[model]
+			}
+
+			// the most recent landings first
+			sort.SliceStable(dates, func(i, j int) bool { return dates[i].date.After(dates[j].date) })
+
+			status := currencyStatus{rule: rule, aircraft: model}
+			periodStart := today.AddDate(0, 0, -rule.days)
+			counted := 0
+
+			for _, d := range dates {
+				if d.landings == 0 {
+					continue
+				}
+
+				if d.date.After(periodStart) {
+					status.landings += d.landings
+				}
+
+				if counted < rule.landings {
+					counted += d.landings
+					if counted >= rule.landings {
+						status.validUntil = d.date.AddDate(0, 0, rule.days-1)
+					}
+				}
+			}
+
+			status.current = !status.validUntil.IsZero() && !status.validUntil.Before(today)
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
@@ -0,0 +1,39 @@
+package logbook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+var testCurrencyRows = [][]interface{}{
+	{"01/09/2021", "LKPR", "0800", "LKPR", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00", "2"},
+	{"15/09/2021", "LKPR", "0800", "LKPD", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00", "1"},
+	{"01/10/2021", "LKPD", "1900", "LKPR", "2000", "C152", "OK-LEB", "01:00", "", "", "01:00", "", "1", "01:00"},
+	{"05/10/2021", "", "", "", "", "B738", "", "", "", "", "", "5", "", "", "", "", "", "", "", "FNPT II", "02:00"},
+	{"01/12/2021", "LKPR", "1900", "LKPR", "2000", "C152", "OK-LEA", "01:00", "", "", "01:00", "", "3", "01:00"},
+}
+
+func TestCalculateCurrency(t *testing.T) {
+	statuses := calculateCurrency(testCurrencyRows, time.Date(2021, 10, 10, 12, 0, 0, 0, time.Local))
+
+	// the simulator sessions and the future flights are not counted
+	assert.Equal(t, len(statuses), 2)
+
+	assert.Equal(t, statuses[0].aircraft, "C152")
+	assert.Equal(t, statuses[0].rule.name, "landings")
+	assert.Equal(t, statuses[0].landings, 4)
+	assert.Equal(t, statuses[0].current, true)
+	assert.Equal(t, statuses[0].validUntil, time.Date(2021, 11, 29, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, statuses[1].rule.name, "night landings")
+	assert.Equal(t, statuses[1].landings, 1)
+	assert.Equal(t, statuses[1].current, false)
+	assert.Equal(t, statuses[1].validUntil.IsZero(), true)
+
+	// the oldest landings are out of the period
+	statuses = calculateCurrency(testCurrencyRows, time.Date(2021, 11, 30, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, statuses[0].landings, 2)
+	assert.Equal(t, statuses[0].current, false)
+}
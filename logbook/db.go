@@ -0,0 +1,294 @@
+package logbook
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// database schema migrations, the index+1 is the schema version
+// stored in the sqlite user_version pragma
+var dbMigrations = []string{
+	`CREATE TABLE aircraft (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model TEXT NOT NULL DEFAULT '',
+		reg TEXT NOT NULL DEFAULT '',
+		UNIQUE (model, reg)
+	);
+	CREATE TABLE flights (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT NOT NULL,
+		departure_place TEXT NOT NULL DEFAULT '',
+		departure_time TEXT NOT NULL DEFAULT '',
+		arrival_place TEXT NOT NULL DEFAULT '',
+		arrival_time TEXT NOT NULL DEFAULT '',
+		aircraft_id INTEGER REFERENCES aircraft(id),
+		se_time INTEGER NOT NULL DEFAULT 0,
+		me_time INTEGER NOT NULL DEFAULT 0,
+		mcc_time INTEGER NOT NULL DEFAULT 0,
+		total_time INTEGER NOT NULL DEFAULT 0,
+		landings_day INTEGER NOT NULL DEFAULT 0,
+		landings_night INTEGER NOT NULL DEFAULT 0,
+		night_time INTEGER NOT NULL DEFAULT 0,
+		ifr_time INTEGER NOT NULL DEFAULT 0,
+		pic_time INTEGER NOT NULL DEFAULT 0,
+		copilot_time INTEGER NOT NULL DEFAULT 0,
+		dual_time INTEGER NOT NULL DEFAULT 0,
+		instructor_time INTEGER NOT NULL DEFAULT 0,
+		pic_name TEXT NOT NULL DEFAULT '',
+		remarks TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX flights_date ON flights (date);
+	CREATE TABLE sims (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT NOT NULL,
+		type TEXT NOT NULL,
+		time INTEGER NOT NULL DEFAULT 0,
+		pic_name TEXT NOT NULL DEFAULT '',
+		remarks TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX sims_date ON sims (date);`,
+}
+
+// dates are stored in ISO format, so they can be sorted
+var dbDateLayout = "2006-01-02"
+
+// openDB opens the sqlite database and applies the schema migrations
+func openDB(fileName string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrateDB(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// migrateDB applies the schema migrations which are not applied yet
+func migrateDB(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("cannot get schema version: %v", err)
+	}
+
+	for i := version; i < len(dbMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(dbMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("cannot apply schema migration %d: %v", i+1, err)
+		}
+
+		// pragma doesn't support parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// minutes returns logbook time in minutes to store it in the database
+func minutes(t logbookTime) int {
+	return int(t.time.Round(time.Minute) / time.Minute)
+}
+
+// fromMinutes returns logbook time from the database value
+func fromMinutes(m int) logbookTime {
+	return logbookTime{time: time.Duration(m) * time.Minute}
+}
+
+// saveDBRecords inserts the logbook records to the database. The row with the
+// simulator session goes to the sims table, the rest goes to the flights table
+//
+// db *sql.DB - database
+//
+// records []logbookRecord - logbook records in chronological order
+func saveDBRecords(db *sql.DB, records []logbookRecord) (flights int, sims int, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, record := range records {
+		date, err := time.Parse(dateLayout, record.date)
+		if err != nil {
+			return 0, 0, fmt.Errorf("wrong date %s: %v", record.date, err)
+		}
+
+		if record.sim.name != "" || record.sim.time.time != 0 {
+			_, err = tx.Exec(`INSERT INTO sims (date, type, time, pic_name, remarks) VALUES (?, ?, ?, ?, ?)`,
+				date.Format(dbDateLayout), record.sim.name, minutes(record.sim.time), record.pic, record.remarks)
+			if err != nil {
+				return 0, 0, err
+			}
+			sims++
+
+			if record.departure.place == "" && record.time.total.time == 0 {
+				continue
+			}
+		}
+
+		_, err = tx.Exec(`INSERT OR IGNORE INTO aircraft (model, reg) VALUES (?, ?)`, record.aircraft.model, record.aircraft.reg)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		_, err = tx.Exec(`INSERT INTO flights (date, departure_place, departure_time, arrival_place, arrival_time, aircraft_id,
+			se_time, me_time, mcc_time, total_time, landings_day, landings_night, night_time, ifr_time,
+			pic_time, copilot_time, dual_time, instructor_time, pic_name, remarks)
+			VALUES (?, ?, ?, ?, ?, (SELECT id FROM aircraft WHERE model = ? AND reg = ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			date.Format(dbDateLayout), record.departure.place, record.departure.time, record.arrival.place, record.arrival.time,
+			record.aircraft.model, record.aircraft.reg,
+			minutes(record.time.se), minutes(record.time.me), minutes(record.time.mcc), minutes(record.time.total),
+			record.landings.day, record.landings.night, minutes(record.time.night), minutes(record.time.ifr),
+			minutes(record.time.pic), minutes(record.time.copilot), minutes(record.time.dual), minutes(record.time.instructor),
+			record.pic, record.remarks)
+		if err != nil {
+			return 0, 0, err
+		}
+		flights++
+	}
+
+	return flights, sims, tx.Commit()
+}
+
+// getDBDump reads the flights and simulator sessions from the database and returns
+// them in the same format as the "Flights" sheet
+func getDBDump(logbookConfig LogbookConfig) (values [][]interface{}, err error) {
+	if _, err := os.Stat(logbookConfig.FileName); err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+
+	db, err := openDB(logbookConfig.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+	defer db.Close()
+
+	order := "ASC"
+	if logbookConfig.Reverse {
+		order = "DESC"
+	}
+
+	rows, err := db.Query(`SELECT f.date, f.departure_place, f.departure_time, f.arrival_place, f.arrival_time,
+			IFNULL(a.model, ''), IFNULL(a.reg, ''), f.se_time, f.me_time, f.mcc_time, f.total_time,
+			f.landings_day, f.landings_night, f.night_time, f.ifr_time, f.pic_time, f.copilot_time,
+			f.dual_time, f.instructor_time, '', 0, f.pic_name, f.remarks, 1 AS kind, f.id
+		FROM flights f LEFT JOIN aircraft a ON a.id = f.aircraft_id
+		UNION ALL
+		SELECT date, '', '', '', '', '', '', 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, type, time, pic_name, remarks, 0 AS kind, id
+		FROM sims
+		ORDER BY 1 ` + order + `, 3 ` + order + `, kind ` + order + `, id ` + order)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve data from database: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record logbookRecord
+		var date string
+		var se, me, mcc, total, night, ifr, pic, copilot, dual, instructor, sim, kind, id int
+
+		err := rows.Scan(&date, &record.departure.place, &record.departure.time, &record.arrival.place, &record.arrival.time,
+			&record.aircraft.model, &record.aircraft.reg, &se, &me, &mcc, &total,
+			&record.landings.day, &record.landings.night, &night, &ifr, &pic, &copilot,
+			&dual, &instructor, &record.sim.name, &sim, &record.pic, &record.remarks, &kind, &id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve data from database: %v", err)
+		}
+
+		if d, err := time.Parse(dbDateLayout, date); err == nil {
+			record.date = d.Format(dateLayout)
+		} else {
+			record.date = date
+		}
+
+		record.time.se = fromMinutes(se)
+		record.time.me = fromMinutes(me)
+		record.time.mcc = fromMinutes(mcc)
+		record.time.total = fromMinutes(total)
+		record.time.night = fromMinutes(night)
+		record.time.ifr = fromMinutes(ifr)
+		record.time.pic = fromMinutes(pic)
+		record.time.copilot = fromMinutes(copilot)
+		record.time.dual = fromMinutes(dual)
+		record.time.instructor = fromMinutes(instructor)
+		record.sim.time = fromMinutes(sim)
+
+		var row []interface{}
+		for _, value := range formatRecord(record) {
+			row = append(row, value)
+		}
+		values = append(values, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to retrieve data from database: %v", err)
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no data found in the database")
+	}
+
+	return values, nil
+}
+
+// Migrate copies the logbook records from the current source (google spreadsheet, local xlsx file)
+// to the local database
+func Migrate(logbookConfig LogbookConfig) {
+
+	response, err := getLogbookDump(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot get logbook dump: %v", err)
+	}
+
+	// the database keeps records in chronological order
+	var records []logbookRecord
+	for _, row := range response {
+		records = append(records, parseRecord(row))
+	}
+	if logbookConfig.Reverse {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+
+	db, err := openDB(logbookConfig.OutputFile)
+	if err != nil {
+		log.Fatalf("Cannot open database: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT (SELECT COUNT(*) FROM flights) + (SELECT COUNT(*) FROM sims)").Scan(&count); err != nil {
+		log.Fatalf("Cannot read database: %v", err)
+	}
+	if count != 0 {
+		log.Fatalf("Database %s already contains %d records", logbookConfig.OutputFile, count)
+	}
+
+	flights, sims, err := saveDBRecords(db, records)
+	if err != nil {
+		log.Fatalf("Cannot save records to the database: %v", err)
+	}
+
+	fmt.Printf("%d flights and %d simulator sessions have been migrated to %s\n", flights, sims, logbookConfig.OutputFile)
+}
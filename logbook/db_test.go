@@ -0,0 +1,62 @@
+package logbook
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestDBRecords(t *testing.T) {
+	rows := [][]interface{}{
+		{"05/02/2020", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "1:30", "", "MEP\\IR"},
+		{"05/02/2020", "LKSZ", "1400", "LKSZ", "1550", "L200", "OK-NXX", "", "1:50", "", "1:50", "16", "", "", "", "", "", "1:50", "", "", "", "PIC Name"},
+		{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "3:35", "3:35", "3:35", "", "1", "", "3:35", "3:35", "", "", "", "", "", "Self"},
+	}
+
+	var records []logbookRecord
+	for _, row := range rows {
+		records = append(records, parseRecord(row))
+	}
+
+	logbookConfig := LogbookConfig{
+		SourceType: "db",
+		FileName:   filepath.Join(t.TempDir(), "logbook.db"),
+	}
+
+	db, err := openDB(logbookConfig.FileName)
+	assert.Equal(t, err, nil)
+
+	flights, sims, err := saveDBRecords(db, records)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, flights, 2)
+	assert.Equal(t, sims, 1)
+	db.Close()
+
+	// migrations are not applied twice
+	db, err = openDB(logbookConfig.FileName)
+	assert.Equal(t, err, nil)
+	db.Close()
+
+	values, err := getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 3)
+
+	record := parseRecord(values[0])
+	assert.Equal(t, record.sim.name, "ULT-28")
+	assert.Equal(t, record.sim.time.GetTime(), "1:30")
+	assert.Equal(t, record.remarks, "MEP\\IR")
+
+	record = parseRecord(values[2])
+	assert.Equal(t, record.date, "08/10/2021")
+	assert.Equal(t, record.aircraft.reg, "OK-TVS")
+	assert.Equal(t, record.time.me.GetTime(), "")
+	assert.Equal(t, record.time.mcc.GetTime(), "3:35")
+	assert.Equal(t, record.landings.night, 1)
+
+	logbookConfig.Reverse = true
+	values, _ = getLogbookDump(logbookConfig)
+	assert.Equal(t, parseRecord(values[0]).date, "08/10/2021")
+	assert.Equal(t, parseRecord(values[1]).departure.place, "LKSZ")
+	assert.Equal(t, parseRecord(values[2]).sim.name, "ULT-28")
+}
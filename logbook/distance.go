@@ -0,0 +1,193 @@
+package logbook
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// mean earth radius in kilometers
+var earthRadius = 6371.0
+
+// kilometers in one nautical mile
+var nauticalMile = 1.852
+
+// routeKey returns the name of the route, the flights in both directions are the same route
+func routeKey(departure string, arrival string) string {
+	if arrival < departure {
+		departure, arrival = arrival, departure
+	}
+
+	return fmt.Sprintf("%s-%s", departure, arrival)
+}
+
+// flightDistance returns the great circle distance of the flight in kilometers
+//
+// record logbookRecord - logbook record
+//
+// airports airportsDB - airports database
+func flightDistance(record logbookRecord, airports airportsDB) (float64, bool) {
+	from, ok := airports.lookup(record.departure.place)
+	if !ok {
+		return 0, false
+	}
+
+	to, ok := airports.lookup(record.arrival.place)
+	if !ok {
+		return 0, false
+	}
+
+	return from.latLng().Distance(to.latLng()).Radians() * earthRadius, true
+}
+
+// formatDistance returns the distance in nautical miles and kilometers
+func formatDistance(km float64) string {
+	return fmt.Sprintf("%.0f NM (%.0f km)", km/nauticalMile, km)
+}
+
+// flightLeg is the flight with the calculated distance
+type flightLeg struct {
+	record   logbookRecord
+	distance float64
+}
+
+// routeCount is the number of flights on the route
+type routeCount struct {
+	route    string
+	flights  int
+	distance float64
+}
+
+// distanceStats contains the distance statistics of the flights
+type distanceStats struct {
+	legs     []flightLeg
+	total    float64
+	longest  flightLeg
+	shortest flightLeg
+	routes   []routeCount
+	speed    float64
+}
+
+// calculateDistanceStats calculates the distances of the flights between the known airports,
+// the local flights are not counted for the shortest leg and the routes
+//
+// rows [][]interface{} - logbook dump
+//
+// airports airportsDB - airports database
+func calculateDistanceStats(rows [][]interface{}, airports airportsDB) distanceStats {
+	var stats distanceStats
+	var totalTime float64
+	var speedDistance float64
+
+	routes := make(map[string]*routeCount)
+
+	for _, row := range rows {
+		record := parseRecord(row)
+		if record.departure.place == "" {
+			continue
+		}
+
+		distance, ok := flightDistance(record, airports)
+		if !ok {
+			continue
+		}
+
+		leg := flightLeg{record: record, distance: distance}
+		stats.legs = append(stats.legs, leg)
+		stats.total += distance
+
+		if distance > stats.longest.distance {
+			stats.longest = leg
+		}
+
+		if record.departure.place == record.arrival.place {
+			continue
+		}
+
+		if stats.shortest.distance == 0 || distance < stats.shortest.distance {
+			stats.shortest = leg
+		}
+
+		key := routeKey(record.departure.place, record.arrival.place)
+		if _, ok := routes[key]; !ok {
+			routes[key] = &routeCount{route: key, distance: distance}
+		}
+		routes[key].flights++
+
+		// the local flights don't have a meaningful speed
+		if record.time.total.time > 0 {
+			totalTime += record.time.total.time.Hours()
+			speedDistance += distance
+		}
+	}
+
+	for _, route := range routes {
+		stats.routes = append(stats.routes, *route)
+	}
+
+	sort.Slice(stats.routes, func(i, j int) bool {
+		if stats.routes[i].flights != stats.routes[j].flights {
+			return stats.routes[i].flights > stats.routes[j].flights
+		}
+		return stats.routes[i].route < stats.routes[j].route
+	})
+
+	if totalTime > 0 {
+		stats.speed = speedDistance / totalTime
+	}
+
+	return stats
+}
+
+// legName returns the date and the route of the flight
+func (leg flightLeg) legName() string {
+	return fmt.Sprintf("%s %s-%s", leg.record.date, leg.record.departure.place, leg.record.arrival.place)
+}
+
+// printDistanceStats prints the distance statistics
+//
+// w io.Writer - output
+//
+// stats distanceStats - distance statistics
+//
+// topRoutes int - number of the most flown routes to print
+func printDistanceStats(w io.Writer, stats distanceStats, topRoutes int) {
+	fmt.Fprintf(w, "Flights: %d\n", len(stats.legs))
+	fmt.Fprintf(w, "Total distance: %s\n", formatDistance(stats.total))
+
+	if len(stats.legs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Longest leg: %s, %s\n", stats.longest.legName(), formatDistance(stats.longest.distance))
+	if stats.shortest.distance > 0 {
+		fmt.Fprintf(w, "Shortest leg: %s, %s\n", stats.shortest.legName(), formatDistance(stats.shortest.distance))
+	}
+	fmt.Fprintf(w, "Average speed: %.0f kt (%.0f km/h)\n", stats.speed/nauticalMile, stats.speed)
+
+	if len(stats.routes) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nMost flown routes")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Route\tFlights\tDistance")
+	for i, route := range stats.routes {
+		if i >= topRoutes {
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", route.route, route.flights, formatDistance(route.distance))
+	}
+	tw.Flush()
+}
+
+// printFlightDistances prints the distance of every flight
+func printFlightDistances(w io.Writer, stats distanceStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Date\tRoute\tNM\tkm")
+	for _, leg := range stats.legs {
+		fmt.Fprintf(tw, "%s\t%s-%s\t%.0f\t%.0f\n", leg.record.date, leg.record.departure.place, leg.record.arrival.place, leg.distance/nauticalMile, leg.distance)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,58 @@
+package logbook
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestDistanceStats(t *testing.T) {
+	rows := [][]interface{}{
+		{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35"},
+		{"09/10/2021", "LKPR", "0600", "LEMG", "0930", "B738", "OK-TVS", "", "03:30", "03:30", "03:30"},
+		{"10/10/2021", "LKPR", "0600", "LKPD", "0630", "B738", "OK-TVS", "", "00:30", "00:30", "00:30"},
+		{"11/10/2021", "LKPR", "0800", "LKPR", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00"},
+		{"12/10/2021", "LKPR", "0600", "XXXX", "0630", "B738", "OK-TVS", "", "00:30", "00:30", "00:30"},
+		{"06/03/2020", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "01:30"},
+	}
+
+	stats := calculateDistanceStats(rows, testAirports)
+	assert.Equal(t, len(stats.legs), 4)
+
+	// LEMG-LKPR is about 1143 NM
+	assert.Equal(t, math.Abs(stats.legs[0].distance/nauticalMile-1143) < 5, true)
+	assert.Equal(t, stats.longest.record.date, "08/10/2021")
+	assert.Equal(t, stats.shortest.record.arrival.place, "LKPD")
+	assert.Equal(t, stats.legs[3].distance, 0.0)
+
+	assert.Equal(t, len(stats.routes), 2)
+	assert.Equal(t, stats.routes[0].route, "LEMG-LKPR")
+	assert.Equal(t, stats.routes[0].flights, 2)
+
+	// the local flight is not counted for the speed
+	assert.Equal(t, stats.speed > 500 && stats.speed < 650, true)
+
+	var buf bytes.Buffer
+	printDistanceStats(&buf, stats, 1)
+	assert.Equal(t, strings.Contains(buf.String(), "Longest leg: 08/10/2021 LEMG-LKPR"), true)
+	assert.Equal(t, strings.Contains(buf.String(), "LKPD-LKPR"), false)
+}
+
+func TestMapLegend(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+
+	legend, err := drawMapLegend(img, []legendLine{{text: "Airports: 3"}, {text: "B738", color: color.Black}}, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, legend.Bounds(), img.Bounds())
+
+	// the legend box is in the bottom left corner
+	_, _, _, a := legend.At(20, 270).RGBA()
+	assert.Equal(t, a > 0, true)
+	_, _, _, a = legend.At(390, 10).RGBA()
+	assert.Equal(t, a, uint32(0))
+}
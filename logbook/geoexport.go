@@ -0,0 +1,399 @@
+package logbook
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// date format of the first and last visits in the exported files
+var geoDateLayout = "2006-01-02"
+
+// geoNetwork is the visited airports and flown routes for the export
+type geoNetwork struct {
+	airports []airportVisits
+	routes   []routeTotals
+}
+
+// visitDate returns the formatted date of the visit, the empty string for the unknown date
+func visitDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(geoDateLayout)
+}
+
+// airportName returns the airport name with the ICAO and IATA codes
+func airportName(airport Airport) string {
+	if airport.IATA != "" {
+		return fmt.Sprintf("%s/%s", airport.ICAO, airport.IATA)
+	}
+	return airport.ICAO
+}
+
+// routeName returns the name of the route
+func routeName(route routeTotals) string {
+	return fmt.Sprintf("%s - %s", airportName(route.departure), airportName(route.arrival))
+}
+
+// airportDescription returns the airport visits as text for the KML and GPX descriptions
+func airportDescription(v airportVisits) string {
+	description := fmt.Sprintf("Visits: %d (%d departures, %d arrivals)\nFirst visit: %s\nLast visit: %s",
+		v.visits(), v.departures, v.arrivals, visitDate(v.firstVisit), visitDate(v.lastVisit))
+
+	if v.airport.Name != "" {
+		description = v.airport.Name + "\n" + description
+	}
+
+	return description
+}
+
+// routeDescription returns the route totals as text for the KML and GPX descriptions
+func routeDescription(route routeTotals) string {
+	total := logbookTime{time: route.time}
+	return fmt.Sprintf("Flights: %d\nTotal time: %s\nDistance: %s", route.flights, total.GetTime(true), formatDistance(route.distance))
+}
+
+// geoJSON types, https://datatracker.ietf.org/doc/html/rfc7946
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// writeGeoJSON writes the airports as points and the routes as great circle lines
+func writeGeoJSON(w io.Writer, network geoNetwork) error {
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	for _, v := range network.airports {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: []float64{v.airport.Lon, v.airport.Lat},
+			},
+			Properties: map[string]interface{}{
+				"type":        "airport",
+				"icao":        v.airport.ICAO,
+				"iata":        v.airport.IATA,
+				"name":        v.airport.Name,
+				"city":        v.airport.City,
+				"country":     v.airport.Country,
+				"visits":      v.visits(),
+				"departures":  v.departures,
+				"arrivals":    v.arrivals,
+				"first_visit": visitDate(v.firstVisit),
+				"last_visit":  visitDate(v.lastVisit),
+			},
+		})
+	}
+
+	for _, route := range network.routes {
+		var lines [][][]float64
+		for _, segment := range greatCircleArc(route.departure.latLng(), route.arrival.latLng()) {
+			var line [][]float64
+			for _, point := range segment {
+				line = append(line, []float64{point.Lng.Degrees(), point.Lat.Degrees()})
+			}
+			lines = append(lines, line)
+		}
+
+		geometry := geoJSONGeometry{Type: "MultiLineString", Coordinates: lines}
+		if len(lines) == 1 {
+			geometry = geoJSONGeometry{Type: "LineString", Coordinates: lines[0]}
+		}
+
+		total := logbookTime{time: route.time}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geometry,
+			Properties: map[string]interface{}{
+				"type":          "route",
+				"departure":     route.departure.ICAO,
+				"arrival":       route.arrival.ICAO,
+				"flights":       route.flights,
+				"total_time":    total.GetTime(true),
+				"total_minutes": int(route.time.Minutes()),
+				"distance_km":   int(route.distance + 0.5),
+				"distance_nm":   int(route.distance/nauticalMile + 0.5),
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
+
+// KML types, https://developers.google.com/kml/documentation/kmlreference
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlMultiGeometry struct {
+	LineStrings []kmlLineString `xml:"LineString"`
+}
+
+type kmlPlacemark struct {
+	Name          string            `xml:"name"`
+	Description   string            `xml:"description"`
+	StyleURL      string            `xml:"styleUrl"`
+	ExtendedData  []kmlData         `xml:"ExtendedData>Data"`
+	Point         *string           `xml:"Point>coordinates"`
+	MultiGeometry *kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlIconStyle struct {
+	Scale float64 `xml:"scale"`
+}
+
+type kmlLineStyle struct {
+	Color string  `xml:"color"`
+	Width float64 `xml:"width"`
+}
+
+type kmlStyle struct {
+	ID        string        `xml:"id,attr"`
+	IconStyle *kmlIconStyle `xml:"IconStyle"`
+	LineStyle *kmlLineStyle `xml:"LineStyle"`
+}
+
+type kmlDocument struct {
+	XMLName xml.Name    `xml:"kml"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Name    string      `xml:"Document>name"`
+	Styles  []kmlStyle  `xml:"Document>Style"`
+	Folders []kmlFolder `xml:"Document>Folder"`
+}
+
+// kmlCoordinates returns the KML point coordinates
+func kmlCoordinates(lon float64, lat float64) string {
+	return fmt.Sprintf("%.6f,%.6f,0", lon, lat)
+}
+
+// writeKML writes the airports and the routes in separate folders, the route lines are
+// tessellated, so they follow the earth surface in Google Earth
+func writeKML(w io.Writer, network geoNetwork) error {
+	doc := kmlDocument{
+		XMLNS: "http://www.opengis.net/kml/2.2",
+		Name:  "Logbook",
+		Styles: []kmlStyle{
+			{ID: "airport", IconStyle: &kmlIconStyle{Scale: 1}},
+			{ID: "route", LineStyle: &kmlLineStyle{Color: "ff0000ff", Width: 2}},
+		},
+	}
+
+	airportsFolder := kmlFolder{Name: "Airports"}
+	for _, v := range network.airports {
+		coordinates := kmlCoordinates(v.airport.Lon, v.airport.Lat)
+		airportsFolder.Placemarks = append(airportsFolder.Placemarks, kmlPlacemark{
+			Name:        airportName(v.airport),
+			Description: airportDescription(v),
+			StyleURL:    "#airport",
+			ExtendedData: []kmlData{
+				{Name: "visits", Value: fmt.Sprint(v.visits())},
+				{Name: "departures", Value: fmt.Sprint(v.departures)},
+				{Name: "arrivals", Value: fmt.Sprint(v.arrivals)},
+				{Name: "first_visit", Value: visitDate(v.firstVisit)},
+				{Name: "last_visit", Value: visitDate(v.lastVisit)},
+			},
+			Point: &coordinates,
+		})
+	}
+
+	routesFolder := kmlFolder{Name: "Routes"}
+	for _, route := range network.routes {
+		var lines []kmlLineString
+		for _, segment := range greatCircleArc(route.departure.latLng(), route.arrival.latLng()) {
+			var points []string
+			for _, point := range segment {
+				points = append(points, kmlCoordinates(point.Lng.Degrees(), point.Lat.Degrees()))
+			}
+			lines = append(lines, kmlLineString{Tessellate: 1, Coordinates: strings.Join(points, " ")})
+		}
+
+		total := logbookTime{time: route.time}
+		routesFolder.Placemarks = append(routesFolder.Placemarks, kmlPlacemark{
+			Name:        routeName(route),
+			Description: routeDescription(route),
+			StyleURL:    "#route",
+			ExtendedData: []kmlData{
+				{Name: "flights", Value: fmt.Sprint(route.flights)},
+				{Name: "total_time", Value: total.GetTime(true)},
+				{Name: "distance_km", Value: fmt.Sprintf("%.0f", route.distance)},
+			},
+			MultiGeometry: &kmlMultiGeometry{LineStrings: lines},
+		})
+	}
+
+	doc.Folders = []kmlFolder{airportsFolder, routesFolder}
+
+	return writeXML(w, doc)
+}
+
+// GPX types, https://www.topografix.com/GPX/1/1/
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name,omitempty"`
+	Desc string  `xml:"desc,omitempty"`
+	Type string  `xml:"type,omitempty"`
+}
+
+type gpxRoute struct {
+	Name   string     `xml:"name"`
+	Desc   string     `xml:"desc"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpxDocument struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	XMLNS     string     `xml:"xmlns,attr"`
+	Version   string     `xml:"version,attr"`
+	Creator   string     `xml:"creator,attr"`
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []gpxRoute `xml:"rte"`
+}
+
+// writeGPX writes the airports as waypoints and the routes from the departure to the arrival
+func writeGPX(w io.Writer, network geoNetwork) error {
+	doc := gpxDocument{
+		XMLNS:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: "logbook",
+	}
+
+	for _, v := range network.airports {
+		doc.Waypoints = append(doc.Waypoints, gpxPoint{
+			Lat:  v.airport.Lat,
+			Lon:  v.airport.Lon,
+			Name: airportName(v.airport),
+			Desc: airportDescription(v),
+			Type: "Airport",
+		})
+	}
+
+	for _, route := range network.routes {
+		doc.Routes = append(doc.Routes, gpxRoute{
+			Name: routeName(route),
+			Desc: routeDescription(route),
+			Points: []gpxPoint{
+				{Lat: route.departure.Lat, Lon: route.departure.Lon, Name: route.departure.ICAO},
+				{Lat: route.arrival.Lat, Lon: route.arrival.Lon, Name: route.arrival.ICAO},
+			},
+		})
+	}
+
+	return writeXML(w, doc)
+}
+
+// writeXML writes the indented xml document with the header
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// geoWriter returns the writer for the file format chosen by the extension
+func geoWriter(fileName string) (func(io.Writer, geoNetwork) error, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".geojson", ".json":
+		return writeGeoJSON, nil
+	case ".kml":
+		return writeKML, nil
+	case ".gpx":
+		return writeGPX, nil
+	}
+
+	return nil, fmt.Errorf("unknown format of %s, should be geojson, kml or gpx", fileName)
+}
+
+// saveGeo saves the airports and routes to the file, the error of the file closing is
+// returned as well, so the partially written file is not reported as exported
+func saveGeo(fileName string, write func(io.Writer, geoNetwork) error, network geoNetwork) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f, network); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// ExportGeo exports the visited airports and the flown routes to GeoJSON, KML or GPX file
+func ExportGeo(logbookConfig LogbookConfig) {
+	write, err := geoWriter(logbookConfig.OutputFile)
+	if err != nil {
+		log.Fatalf("Cannot export airports and routes: %v", err)
+	}
+
+	// load airports database
+	airports, err := loadAirportsDB(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot load airports database: %v", err)
+	}
+
+	response, err := getLogbookDump(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot get logbook dump: %v", err)
+	}
+
+	rows := filterRowsByDate(response, logbookConfig.FilterDate)
+
+	var network geoNetwork
+	var missingAirports []string
+	network.airports, missingAirports = collectAirportVisits(rows, airports)
+	if !logbookConfig.FilterNoRoutes {
+		network.routes = collectRouteTotals(rows, airports)
+	}
+
+	if len(missingAirports) > 0 {
+		fmt.Printf("Cannot place airports (add them to the custom airports file): %s\n", strings.Join(missingAirports, ", "))
+	}
+
+	if err := saveGeo(logbookConfig.OutputFile, write, network); err != nil {
+		log.Fatalf("Cannot export airports and routes: %v", err)
+	}
+
+	fmt.Printf("%d airports and %d routes have been exported to %s\n", len(network.airports), len(network.routes), logbookConfig.OutputFile)
+}
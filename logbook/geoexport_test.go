@@ -0,0 +1,99 @@
+package logbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestAirportVisits(t *testing.T) {
+	visits, missing := collectAirportVisits(testMapRows, testAirports)
+	assert.Equal(t, missing, []string{"ZZZZ"})
+	assert.Equal(t, len(visits), 3)
+
+	assert.Equal(t, visits[2].code, "LKPR")
+	assert.Equal(t, visits[2].departures, 2)
+	assert.Equal(t, visits[2].arrivals, 1)
+	assert.Equal(t, visits[2].visits(), 3)
+	assert.Equal(t, visits[2].firstVisit, time.Date(2021, 10, 8, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, visits[2].lastVisit, time.Date(2021, 10, 11, 0, 0, 0, 0, time.UTC))
+
+	routes := collectRouteTotals(testMapRows, testAirports)
+	assert.Equal(t, len(routes), 2)
+	assert.Equal(t, routes[0].departure.ICAO, "LEMG")
+	assert.Equal(t, routes[0].arrival.ICAO, "LKPR")
+	assert.Equal(t, routes[0].flights, 2)
+	assert.Equal(t, routes[0].time, 7*time.Hour+5*time.Minute)
+
+	assert.Equal(t, len(filterRowsByDate(testMapRows, "11/10")), 1)
+	assert.Equal(t, len(filterRowsByDate(testMapRows, "")), len(testMapRows))
+}
+
+func TestGeoExport(t *testing.T) {
+	var network geoNetwork
+	network.airports, _ = collectAirportVisits(testMapRows, testAirports)
+	network.routes = collectRouteTotals(testMapRows, testAirports)
+
+	// geojson
+	var buf bytes.Buffer
+	assert.Equal(t, writeGeoJSON(&buf, network), nil)
+
+	var collection struct {
+		Features []struct {
+			Geometry struct {
+				Type string
+			}
+			Properties map[string]interface{}
+		}
+	}
+	assert.Equal(t, json.Unmarshal(buf.Bytes(), &collection), nil)
+	assert.Equal(t, len(collection.Features), 5)
+	assert.Equal(t, collection.Features[0].Geometry.Type, "Point")
+	assert.Equal(t, collection.Features[2].Properties["first_visit"], "2021-10-08")
+	assert.Equal(t, collection.Features[3].Geometry.Type, "LineString")
+	assert.Equal(t, collection.Features[3].Properties["total_time"], "7:05")
+	assert.Equal(t, collection.Features[3].Properties["flights"], 2.0)
+
+	// kml
+	buf.Reset()
+	assert.Equal(t, writeKML(&buf, network), nil)
+	assert.Equal(t, xml.Unmarshal(buf.Bytes(), new(kmlDocument)), nil)
+	assert.Equal(t, strings.Count(buf.String(), "<Placemark>"), 5)
+	assert.Equal(t, strings.Count(buf.String(), "<tessellate>1</tessellate>"), 2)
+
+	// gpx
+	buf.Reset()
+	assert.Equal(t, writeGPX(&buf, network), nil)
+	var gpx gpxDocument
+	assert.Equal(t, xml.Unmarshal(buf.Bytes(), &gpx), nil)
+	assert.Equal(t, len(gpx.Waypoints), 3)
+	assert.Equal(t, len(gpx.Routes), 2)
+	assert.Equal(t, gpx.Routes[0].Points[1].Name, "LKPR")
+
+	for _, fileName := range []string{"map.geojson", "map.KML", "map.gpx"} {
+		_, err := geoWriter(fileName)
+		assert.Equal(t, err, nil)
+	}
+	_, err := geoWriter("map.shp")
+	assert.Equal(t, err != nil, true)
+
+	// the file is saved and the write errors are returned
+	fileName := filepath.Join(t.TempDir(), "map.geojson")
+	assert.Equal(t, saveGeo(fileName, writeGeoJSON, network), nil)
+	data, err := os.ReadFile(fileName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, json.Valid(data), true)
+
+	writeErr := fmt.Errorf("disk full")
+	assert.Equal(t, saveGeo(fileName, func(io.Writer, geoNetwork) error { return writeErr }, network), writeErr)
+	assert.Equal(t, saveGeo(filepath.Join(fileName, "map.kml"), writeKML, network) != nil, true)
+}
@@ -0,0 +1,209 @@
+package logbook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// googleCache is the last fetched data from the google spreadsheet
+type googleCache struct {
+	SpreadsheetID string          `json:"spreadsheet_id"`
+	Range         string          `json:"range"`
+	FetchedAt     time.Time       `json:"fetched_at"`
+	Values        [][]interface{} `json:"values"`
+}
+
+// googleRange returns the range of the sheet with logbook records, the sheet name is quoted,
+// so it can contain spaces and punctuation
+func googleRange(logbookConfig LogbookConfig) string {
+	sheet := strings.ReplaceAll(logbookConfig.sheet(), "'", "''")
+	return fmt.Sprintf("'%s'!A%d:W", sheet, logbookConfig.StartRow)
+}
+
+// googleCacheFile returns the cache file name for the spreadsheet
+func googleCacheFile(logbookConfig LogbookConfig) (string, error) {
+	dir := logbookConfig.CacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(userDir, "logbook")
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("google-%s-%s.json", logbookConfig.SpreadsheetID, logbookConfig.sheet())), nil
+}
+
+// loadGoogleCache reads the cached spreadsheet values
+func loadGoogleCache(logbookConfig LogbookConfig) (cache googleCache, err error) {
+	fileName, err := googleCacheFile(logbookConfig)
+	if err != nil {
+		return cache, err
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return cache, err
+	}
+
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return cache, fmt.Errorf("cannot parse cache file %s: %v", fileName, err)
+	}
+
+	if cache.Range != googleRange(logbookConfig) {
+		return cache, fmt.Errorf("cache file %s contains different range %s", fileName, cache.Range)
+	}
+
+	return cache, nil
+}
+
+// saveGoogleCache stores the spreadsheet values to the cache file
+func saveGoogleCache(logbookConfig LogbookConfig, values [][]interface{}) (string, error) {
+	fileName, err := googleCacheFile(logbookConfig)
+	if err != nil {
+		return "", err
+	}
+
+	cache := googleCache{
+		SpreadsheetID: logbookConfig.SpreadsheetID,
+		Range:         googleRange(logbookConfig),
+		FetchedAt:     time.Now().UTC(),
+		Values:        values,
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return "", err
+	}
+
+	return fileName, os.WriteFile(fileName, data, 0644)
+}
+
+// fetchGoogleValues gets the logbook records from the google spreadsheet
+func fetchGoogleValues(logbookConfig LogbookConfig) ([][]interface{}, error) {
+	ctx := context.Background()
+
+	options, err := googleClientOptions(ctx, logbookConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := sheets.NewService(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Google Spreadsheets client: %v", err)
+	}
+
+	response, err := srv.Spreadsheets.Values.Get(logbookConfig.SpreadsheetID, googleRange(logbookConfig)).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve data from sheet: %v", err)
+	}
+
+	if len(response.Values) == 0 {
+		return nil, fmt.Errorf("no data found in the sheet")
+	}
+
+	return response.Values, nil
+}
+
+// getGoogleDump reads the logbook from the google spreadsheet and caches it locally.
+// In case the spreadsheet is not reachable the cached values are used
+func getGoogleDump(logbookConfig LogbookConfig) ([][]interface{}, error) {
+	values, err := fetchGoogleValues(logbookConfig)
+	if err != nil {
+		cache, cacheErr := loadGoogleCache(logbookConfig)
+		if cacheErr != nil {
+			return nil, err
+		}
+
+		log.Printf("Warning: %v, using cached data fetched at %s\n", err, cache.FetchedAt.Local().Format("2006-01-02 15:04"))
+		return cache.Values, nil
+	}
+
+	if _, err := saveGoogleCache(logbookConfig, values); err != nil {
+		log.Printf("Warning: cannot save cache: %v\n", err)
+	}
+
+	return values, nil
+}
+
+// rowsDiff returns the number of rows which exist only in the first or only in the second list
+func rowsDiff(old [][]interface{}, new [][]interface{}) (added int, removed int) {
+	key := func(row []interface{}) string {
+		var cells []string
+		for _, cell := range row {
+			cells = append(cells, fmt.Sprintf("%v", cell))
+		}
+		return strings.TrimRight(strings.Join(cells, "\t"), "\t")
+	}
+
+	counts := make(map[string]int)
+	for _, row := range old {
+		counts[key(row)]++
+	}
+
+	for _, row := range new {
+		if counts[key(row)] > 0 {
+			counts[key(row)]--
+		} else {
+			added++
+		}
+	}
+
+	for _, count := range counts {
+		removed += count
+	}
+
+	return added, removed
+}
+
+// Sync fetches the google spreadsheets and updates the local cache
+func Sync(logbookConfig LogbookConfig) {
+	configs := []LogbookConfig{logbookConfig}
+	if len(logbookConfig.Sources) > 0 {
+		configs = sourceConfigs(logbookConfig)
+	}
+
+	synced := 0
+	for _, config := range configs {
+		if config.SourceType != "google" {
+			continue
+		}
+
+		values, err := fetchGoogleValues(config)
+		if err != nil {
+			log.Fatalf("Cannot sync logbook: %v", err)
+		}
+
+		var added, removed int
+		cache, err := loadGoogleCache(config)
+		if err == nil {
+			added, removed = rowsDiff(cache.Values, values)
+		} else {
+			added = len(values)
+		}
+
+		fileName, err := saveGoogleCache(config, values)
+		if err != nil {
+			log.Fatalf("Cannot save cache: %v", err)
+		}
+
+		fmt.Printf("%d rows have been synced to %s: %d new, %d removed\n", len(values), fileName, added, removed)
+		synced++
+	}
+
+	if synced == 0 {
+		log.Fatalf("Only google spreadsheet source can be synced")
+	}
+}
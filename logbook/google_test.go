@@ -0,0 +1,69 @@
+package logbook
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestGoogleCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !strings.HasPrefix(r.URL.Path, "/v4/spreadsheets/test-id/values/") || r.URL.Query().Get("key") != "test-key" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"range": "Flights!A3:W5", "majorDimension": "ROWS", "values": [
+			["08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35", "", "1"],
+			["06/03/2020", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "01:30"]
+		]}`)
+	}))
+
+	logbookConfig := LogbookConfig{
+		SourceType:     "google",
+		APIKey:         "test-key",
+		SpreadsheetID:  "test-id",
+		StartRow:       3,
+		CacheDir:       t.TempDir(),
+		GoogleEndpoint: server.URL + "/",
+	}
+
+	values, err := getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, requests, 1)
+	assert.Equal(t, len(values), 2)
+	assert.Equal(t, parseRecord(values[0]).arrival.place, "LKPR")
+
+	// the spreadsheet is not reachable, so the cached values are used
+	server.Close()
+
+	values, err = getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 2)
+	assert.Equal(t, parseRecord(values[1]).sim.name, "ULT-28")
+
+	// no cache for the other range
+	logbookConfig.StartRow = 5
+	_, err = getLogbookDump(logbookConfig)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestGoogleRange(t *testing.T) {
+	assert.Equal(t, googleRange(LogbookConfig{StartRow: 3}), "'Flights'!A3:W")
+	assert.Equal(t, googleRange(LogbookConfig{SheetName: "My Flights", StartRow: 1}), "'My Flights'!A1:W")
+	assert.Equal(t, googleRange(LogbookConfig{SheetName: "Pilot's 2023-24", StartRow: 2}), "'Pilot''s 2023-24'!A2:W")
+}
+
+func TestRowsDiff(t *testing.T) {
+	old := [][]interface{}{{"a", "1"}, {"b", "2"}, {"b", "2"}}
+	new := [][]interface{}{{"c", "3"}, {"a", "1", ""}, {"b", "2"}}
+
+	added, removed := rowsDiff(old, new)
+	assert.Equal(t, added, 1)
+	assert.Equal(t, removed, 1)
+}
@@ -0,0 +1,246 @@
+package logbook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// oauthAuthorizeTimeout is the time to complete the authorization in the browser
+var oauthAuthorizeTimeout = 5 * time.Minute
+
+// googleClientOptions returns the client options for the configured authentication method:
+// "api_key" (default) for the spreadsheets shared by link, "service_account" and "oauth"
+// for the private spreadsheets
+func googleClientOptions(ctx context.Context, logbookConfig LogbookConfig) ([]option.ClientOption, error) {
+	var options []option.ClientOption
+
+	switch logbookConfig.GoogleAuth {
+	case "", "api_key":
+		options = append(options, option.WithAPIKey(logbookConfig.APIKey))
+
+	case "service_account":
+		options = append(options,
+			option.WithCredentialsFile(logbookConfig.CredentialsFile),
+			option.WithScopes(sheets.SpreadsheetsReadonlyScope),
+		)
+
+	case "oauth":
+		tokenSource, err := oauthTokenSource(ctx, logbookConfig)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, option.WithTokenSource(tokenSource))
+
+	default:
+		return nil, fmt.Errorf("unknown google authentication method %s", logbookConfig.GoogleAuth)
+	}
+
+	if logbookConfig.GoogleEndpoint != "" {
+		options = append(options, option.WithEndpoint(logbookConfig.GoogleEndpoint))
+	}
+
+	return options, nil
+}
+
+// oauthTokenFile returns the file name for the cached oauth token
+func oauthTokenFile(logbookConfig LogbookConfig) (string, error) {
+	if logbookConfig.TokenFile != "" {
+		return logbookConfig.TokenFile, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "logbook", "token.json"), nil
+}
+
+// loadOAuthToken reads the cached oauth token
+func loadOAuthToken(fileName string) (*oauth2.Token, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("cannot parse token file %s: %v", fileName, err)
+	}
+
+	return &token, nil
+}
+
+// saveOAuthToken stores the oauth token, it's readable for the user only
+func saveOAuthToken(fileName string, token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(fileName, data, 0600)
+}
+
+// cachingTokenSource saves the token every time it's refreshed
+type cachingTokenSource struct {
+	source   oauth2.TokenSource
+	fileName string
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func (s *cachingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil || s.token.AccessToken != token.AccessToken {
+		if err := saveOAuthToken(s.fileName, token); err != nil {
+			return nil, fmt.Errorf("cannot save token: %v", err)
+		}
+		s.token = token
+	}
+
+	return token, nil
+}
+
+// oauthConfig reads the oauth client ID file
+func oauthConfig(logbookConfig LogbookConfig) (*oauth2.Config, error) {
+	data, err := os.ReadFile(logbookConfig.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read credentials file: %v", err)
+	}
+
+	config, err := google.ConfigFromJSON(data, sheets.SpreadsheetsReadonlyScope)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse credentials file: %v", err)
+	}
+
+	return config, nil
+}
+
+// oauthTokenSource returns the token source for the installed application oauth flow.
+// The token is obtained by the auth command, so the logbook can be read non-interactively
+func oauthTokenSource(ctx context.Context, logbookConfig LogbookConfig) (oauth2.TokenSource, error) {
+	config, err := oauthConfig(logbookConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName, err := oauthTokenFile(logbookConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := loadOAuthToken(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("no oauth token (%v), run logbook auth first", err)
+	}
+
+	return &cachingTokenSource{
+		source:   config.TokenSource(ctx, token),
+		fileName: fileName,
+		token:    token,
+	}, nil
+}
+
+// Authorize asks the user to authorize the access to the spreadsheet in the browser and
+// saves the oauth token
+func Authorize(logbookConfig LogbookConfig) {
+	config, err := oauthConfig(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot authorize: %v", err)
+	}
+
+	fileName, err := oauthTokenFile(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot authorize: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oauthAuthorizeTimeout)
+	defer cancel()
+
+	token, err := oauthAuthorize(ctx, config)
+	if err != nil {
+		log.Fatalf("Cannot authorize: %v", err)
+	}
+
+	if err := saveOAuthToken(fileName, token); err != nil {
+		log.Fatalf("Cannot save token: %v", err)
+	}
+
+	fmt.Printf("Token has been saved to %s\n", fileName)
+}
+
+// oauthAuthorize asks the user to authorize the application in the browser and receives
+// the authorization code on the local address, the error is returned when the context is done
+func oauthAuthorize(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("cannot start local server for authorization: %v", err)
+	}
+	defer listener.Close()
+
+	config.RedirectURL = fmt.Sprintf("http://%s/", listener.Addr().String())
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(b)
+
+	codes := make(chan string, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != state || r.URL.Query().Get("code") == "" {
+			http.Error(w, "Wrong authorization response", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "Authorization is completed, you can close this page")
+		select {
+		case codes <- r.URL.Query().Get("code"):
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Printf("Open the link in the browser to authorize access to the spreadsheet:\n%s\n", config.AuthCodeURL(state, oauth2.AccessTypeOffline))
+
+	select {
+	case code := <-codes:
+		token, err := config.Exchange(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get token: %v", err)
+		}
+		return token, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("authorization is not completed in %v", oauthAuthorizeTimeout)
+		}
+		return nil, ctx.Err()
+	}
+}
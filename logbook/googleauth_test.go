@@ -0,0 +1,143 @@
+package logbook
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+	"golang.org/x/oauth2"
+)
+
+// newGoogleServer returns the stand-in server for the token and spreadsheets endpoints
+func newGoogleServer(authorization *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token": "service-token", "token_type": "Bearer", "expires_in": 3600}`)
+			return
+		}
+
+		*authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"values": [["08/10/2021", "LEMG", "1930", "LKPR", "2305"]]}`)
+	}))
+}
+
+func TestGoogleServiceAccount(t *testing.T) {
+	var authorization string
+	server := newGoogleServer(&authorization)
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Equal(t, err, nil)
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Equal(t, err, nil)
+
+	credentials, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "logbook",
+		"private_key_id": "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		"client_email":   "logbook@logbook.iam.gserviceaccount.com",
+		"client_id":      "1",
+		"token_uri":      server.URL + "/token",
+	})
+	credentialsFile := filepath.Join(t.TempDir(), "service-account.json")
+	os.WriteFile(credentialsFile, credentials, 0600)
+
+	logbookConfig := LogbookConfig{
+		SourceType:      "google",
+		SpreadsheetID:   "test-id",
+		StartRow:        3,
+		CacheDir:        t.TempDir(),
+		GoogleEndpoint:  server.URL + "/",
+		GoogleAuth:      "service_account",
+		CredentialsFile: credentialsFile,
+	}
+
+	values, err := fetchGoogleValues(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 1)
+	assert.Equal(t, strings.HasPrefix(authorization, "Bearer "), true)
+}
+
+func TestGoogleOAuthCachedToken(t *testing.T) {
+	var authorization string
+	server := newGoogleServer(&authorization)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	credentialsFile := filepath.Join(dir, "client.json")
+	os.WriteFile(credentialsFile, []byte(fmt.Sprintf(`{"installed": {"client_id": "id", "client_secret": "secret",
+		"auth_uri": "%[1]s/auth", "token_uri": "%[1]s/token", "redirect_uris": ["http://localhost"]}}`, server.URL)), 0600)
+
+	tokenFile := filepath.Join(dir, "token.json")
+	err := saveOAuthToken(tokenFile, &oauth2.Token{AccessToken: "cached-token", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)})
+	assert.Equal(t, err, nil)
+
+	logbookConfig := LogbookConfig{
+		SourceType:      "google",
+		SpreadsheetID:   "test-id",
+		StartRow:        3,
+		GoogleEndpoint:  server.URL + "/",
+		GoogleAuth:      "oauth",
+		CredentialsFile: credentialsFile,
+		TokenFile:       tokenFile,
+	}
+
+	_, err = fetchGoogleValues(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, authorization, "Bearer cached-token")
+
+	logbookConfig.GoogleAuth = "password"
+	_, err = fetchGoogleValues(logbookConfig)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestGoogleOAuthNoToken(t *testing.T) {
+	var authorization string
+	server := newGoogleServer(&authorization)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	credentialsFile := filepath.Join(dir, "client.json")
+	os.WriteFile(credentialsFile, []byte(fmt.Sprintf(`{"installed": {"client_id": "id", "client_secret": "secret",
+		"auth_uri": "%[1]s/auth", "token_uri": "%[1]s/token", "redirect_uris": ["http://localhost"]}}`, server.URL)), 0600)
+
+	logbookConfig := LogbookConfig{
+		SourceType:      "google",
+		SpreadsheetID:   "test-id",
+		StartRow:        3,
+		CacheDir:        dir,
+		GoogleEndpoint:  server.URL + "/",
+		GoogleAuth:      "oauth",
+		CredentialsFile: credentialsFile,
+		TokenFile:       filepath.Join(dir, "token.json"),
+	}
+
+	// no authorization in the browser, the error is returned without waiting
+	_, err := fetchGoogleValues(logbookConfig)
+	assert.Equal(t, err != nil, true)
+	assert.Equal(t, strings.Contains(err.Error(), "run logbook auth first"), true)
+	assert.Equal(t, authorization, "")
+
+	// the cached records are used instead
+	_, err = saveGoogleCache(logbookConfig, [][]interface{}{{"08/10/2021", "LEMG", "1930", "LKPR", "2305"}})
+	assert.Equal(t, err, nil)
+
+	values, err := getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 1)
+}
@@ -0,0 +1,58 @@
+package logbook
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+)
+
+// distance in kilometers between the interpolated points of the great circle arc
+var arcStep = 100.0
+
+// greatCircleArc returns the points of the great circle route between two locations.
+// The route is split into several segments in case it crosses the antimeridian, otherwise
+// the map draws the line across the whole image
+//
+// from s2.LatLng - departure location
+//
+// to s2.LatLng - arrival location
+func greatCircleArc(from s2.LatLng, to s2.LatLng) [][]s2.LatLng {
+	a := s2.PointFromLatLng(from)
+	b := s2.PointFromLatLng(to)
+
+	steps := int(math.Ceil(from.Distance(to).Radians() * earthRadius / arcStep))
+	if steps < 1 {
+		steps = 1
+	}
+
+	var segments [][]s2.LatLng
+	segment := []s2.LatLng{from}
+
+	prev := from
+	for i := 1; i <= steps; i++ {
+		point := to
+		if i < steps {
+			point = s2.LatLngFromPoint(s2.Interpolate(float64(i)/float64(steps), a, b))
+		}
+
+		prevLng := prev.Lng.Degrees()
+		lng := point.Lng.Degrees()
+
+		if math.Abs(lng-prevLng) > 180 {
+			// the antimeridian is crossed, find the latitude on it
+			side := math.Copysign(180, prevLng)
+			unwrapped := lng + 2*side
+			t := (side - prevLng) / (unwrapped - prevLng)
+			lat := prev.Lat.Degrees() + t*(point.Lat.Degrees()-prev.Lat.Degrees())
+
+			segment = append(segment, s2.LatLngFromDegrees(lat, side))
+			segments = append(segments, segment)
+			segment = []s2.LatLng{s2.LatLngFromDegrees(lat, -side)}
+		}
+
+		segment = append(segment, point)
+		prev = point
+	}
+
+	return append(segments, segment)
+}
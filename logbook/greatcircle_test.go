@@ -0,0 +1,51 @@
+package logbook
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestGreatCircleArc(t *testing.T) {
+	// about 2116 km, so the point every 100 km
+	lemg := s2.LatLngFromDegrees(36.6749, -4.49911)
+	lkpr := s2.LatLngFromDegrees(50.1008, 14.26)
+
+	segments := greatCircleArc(lemg, lkpr)
+	assert.Equal(t, len(segments), 1)
+	assert.Equal(t, len(segments[0]), 23)
+	assert.Equal(t, segments[0][0], lemg)
+	assert.Equal(t, segments[0][22], lkpr)
+
+	// Tokyo to San Francisco crosses the antimeridian
+	rjaa := s2.LatLngFromDegrees(35.7647, 140.386)
+	ksfo := s2.LatLngFromDegrees(37.619, -122.375)
+
+	segments = greatCircleArc(rjaa, ksfo)
+	assert.Equal(t, len(segments), 2)
+
+	end := segments[0][len(segments[0])-1]
+	start := segments[1][0]
+	assert.Equal(t, end.Lng.Degrees(), 180.0)
+	assert.Equal(t, start.Lng.Degrees(), -180.0)
+	assert.Equal(t, math.Abs(end.Lat.Degrees()-start.Lat.Degrees()) < 1e-9, true)
+
+	maxLat := 0.0
+	for _, segment := range segments {
+		for i, point := range segment {
+			maxLat = math.Max(maxLat, point.Lat.Degrees())
+			if i > 0 {
+				// no jumps across the map inside the segment
+				assert.Equal(t, math.Abs(point.Lng.Degrees()-segment[i-1].Lng.Degrees()) < 180, true)
+			}
+		}
+	}
+	assert.Equal(t, maxLat > 47, true)
+
+	// the same airport
+	segments = greatCircleArc(lkpr, lkpr)
+	assert.Equal(t, len(segments), 1)
+	assert.Equal(t, len(segments[0]), 2)
+}
@@ -0,0 +1,137 @@
+package logbook
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/s2"
+)
+
+// default heatmap radius in pixels at the default resolution
+var defaultHeatmapRadius = 40.0
+
+// default heatmap colour ramp from the rarely to the most visited airports
+var defaultHeatmapColors = []string{"#0000ff", "#00ffff", "#00ff00", "#ffff00", "#ff0000"}
+
+// opacity of the most dense heatmap area
+var heatmapMaxAlpha = 0.8
+
+// heatPoint is the airport with its weight for the heatmap
+type heatPoint struct {
+	position s2.LatLng
+	weight   float64
+}
+
+// parseHexColor parses the colour in #rrggbb or #rgb format
+func parseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = strings.Repeat(hex[0:1], 2) + strings.Repeat(hex[1:2], 2) + strings.Repeat(hex[2:3], 2)
+	}
+
+	if len(hex) != 6 {
+		return color.NRGBA{}, fmt.Errorf("wrong colour %s, should be #rrggbb", s)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("wrong colour %s, should be #rrggbb", s)
+	}
+
+	return color.NRGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}, nil
+}
+
+// parseColorRamp parses the list of colours, the default ramp is used for the empty list
+func parseColorRamp(colors []string) ([]color.NRGBA, error) {
+	if len(colors) == 0 {
+		colors = defaultHeatmapColors
+	}
+
+	var ramp []color.NRGBA
+	for _, c := range colors {
+		rampColor, err := parseHexColor(c)
+		if err != nil {
+			return nil, err
+		}
+		ramp = append(ramp, rampColor)
+	}
+
+	return ramp, nil
+}
+
+// rampColor returns the colour of the ramp for the value from 0 to 1
+func rampColor(ramp []color.NRGBA, value float64) color.NRGBA {
+	if len(ramp) == 1 || value <= 0 {
+		return ramp[0]
+	}
+	if value >= 1 {
+		return ramp[len(ramp)-1]
+	}
+
+	position := value * float64(len(ramp)-1)
+	i := int(position)
+	t := position - float64(i)
+
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+
+	from, to := ramp[i], ramp[i+1]
+	return color.NRGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
+
+// drawHeatmap returns the transparent overlay with the density of the airport visits. The
+// density is logarithmic, so the rarely visited airports are still visible next to the
+// home base
+func drawHeatmap(scene mapScene) *image.NRGBA {
+	overlay := image.NewNRGBA(image.Rect(0, 0, scene.width, scene.height))
+	if len(scene.heat) == 0 || scene.heatRadius <= 0 {
+		return overlay
+	}
+
+	density := make([]float64, scene.width*scene.height)
+	radius := scene.heatRadius
+
+	for _, point := range scene.heat {
+		px, py := scene.project(point.position)
+
+		for y := int(math.Max(0, py-radius)); y < int(math.Min(float64(scene.height), py+radius+1)); y++ {
+			for x := int(math.Max(0, px-radius)); x < int(math.Min(float64(scene.width), px+radius+1)); x++ {
+				d := math.Hypot(float64(x)-px, float64(y)-py) / radius
+				if d >= 1 {
+					continue
+				}
+
+				// smooth kernel, which is 1 in the center and 0 on the radius
+				k := 1 - d*d
+				density[y*scene.width+x] += point.weight * k * k
+			}
+		}
+	}
+
+	maxDensity := 0.0
+	for _, value := range density {
+		maxDensity = math.Max(maxDensity, value)
+	}
+	if maxDensity == 0 {
+		return overlay
+	}
+
+	for i, value := range density {
+		if value == 0 {
+			continue
+		}
+
+		intensity := math.Log1p(value) / math.Log1p(maxDensity)
+		c := rampColor(scene.heatRamp, intensity)
+		c.A = uint8(math.Round(0xff * heatmapMaxAlpha * math.Sqrt(intensity)))
+
+		overlay.SetNRGBA(i%scene.width, i/scene.width, c)
+	}
+
+	return overlay
+}
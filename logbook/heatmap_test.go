@@ -0,0 +1,57 @@
+package logbook
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestColorRamp(t *testing.T) {
+	c, err := parseHexColor("#ff8000")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, c, color.NRGBA{0xff, 0x80, 0x00, 0xff})
+
+	c, err = parseHexColor("0f0")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, c, color.NRGBA{0x00, 0xff, 0x00, 0xff})
+
+	_, err = parseHexColor("#ff80")
+	assert.Equal(t, err != nil, true)
+	_, err = parseHexColor("#gg0000")
+	assert.Equal(t, err != nil, true)
+
+	ramp, err := parseColorRamp(nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(ramp), len(defaultHeatmapColors))
+
+	ramp, err = parseColorRamp([]string{"#000000", "#ffffff"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rampColor(ramp, 0), color.NRGBA{0, 0, 0, 0xff})
+	assert.Equal(t, rampColor(ramp, 0.5), color.NRGBA{0x80, 0x80, 0x80, 0xff})
+	assert.Equal(t, rampColor(ramp, 2), color.NRGBA{0xff, 0xff, 0xff, 0xff})
+}
+
+func TestHeatmap(t *testing.T) {
+	cfg := LogbookConfig{MapWidth: 400, MapHeight: 300, MapHeatmap: true, HeatmapRadius: 10, HeatmapColors: []string{"#0000ff", "#ff0000"}}
+	scene, err := buildMapScene(cfg, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(scene.markers), 0)
+	assert.Equal(t, len(scene.heat), 3)
+	assert.Equal(t, scene.heatRadius, 10.0)
+
+	overlay := drawHeatmap(scene)
+
+	// LKPR has more visits than LEMG
+	x, y := scene.project(testAirports.airports["LKPR"].latLng())
+	prague := overlay.NRGBAAt(int(x), int(y))
+	x, y = scene.project(testAirports.airports["LEMG"].latLng())
+	malaga := overlay.NRGBAAt(int(x), int(y))
+
+	assert.Equal(t, prague.A > 0 && malaga.A > 0, true)
+	assert.Equal(t, prague.R > malaga.R, true)
+	assert.Equal(t, overlay.NRGBAAt(int(x)+20, int(y)).A, uint8(0))
+
+	_, err = buildMapScene(LogbookConfig{MapHeatmap: true, HeatmapColors: []string{"red"}}, testMapRows, testAirports)
+	assert.Equal(t, err != nil, true)
+}
@@ -0,0 +1,624 @@
+package logbook
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// the first row with the flight data in the imported xlsx file
+var importStartRow = 3
+
+// headers of the "Flights" sheet, the same as in the internal/logbook.xlsx
+var importHeader1 = []string{"Date", "Departure", "", "Arrival", "", "Aircraft", "", "Pilot Time", "", "", "Total Time (w/o sim)", "Landings", "", "Operation Condition Time", "", "Pilot Function Time", "", "", "", "Simulator", "", "PIC Name", "Remarks"}
+var importHeader2 = []string{"", "Place", "Time", "Place", "Time", "Model", "Reg", "SE", "ME", "MCC", "", "D", "N", "Night", "IFR", "PIC", "Co-Pilot", "Dual", "Instructor", "Type", "Time", "", ""}
+
+// importFormat describes the csv export of the other logbook application
+type importFormat struct {
+	// layouts to parse the date field
+	dateLayouts []string
+	// durations without ":" are in minutes instead of decimal hours
+	minutes bool
+	// mapping of the csv field names to the logbook fields
+	fields map[string]string
+}
+
+// supported import formats, the field names are compared in lower case
+// and without spaces and punctuation
+var importFormats = map[string]importFormat{
+	"foreflight": {
+		dateLayouts: []string{"2006-01-02", "01/02/2006"},
+		fields: map[string]string{
+			"date":                  "date",
+			"aircraftid":            "aircraft.reg",
+			"from":                  "departure.place",
+			"to":                    "arrival.place",
+			"timeout":               "departure.time",
+			"timein":                "arrival.time",
+			"totaltime":             "time.total",
+			"pic":                   "time.pic",
+			"sic":                   "time.copilot",
+			"night":                 "time.night",
+			"actualinstrument":      "time.ifr",
+			"simulatedinstrument":   "time.ifr",
+			"dualreceived":          "time.dual",
+			"dualgiven":             "time.instructor",
+			"simulatedflight":       "sim.time",
+			"daylandingsfullstop":   "landings.day",
+			"nightlandingsfullstop": "landings.night",
+			"alllandings":           "landings.all",
+			"instructorname":        "pic",
+			"pilotcomments":         "remarks",
+			"person1":               "person",
+			"person2":               "person",
+			"person3":               "person",
+			"person4":               "person",
+			"person5":               "person",
+			"person6":               "person",
+		},
+	},
+	"logten": {
+		dateLayouts: []string{"2006-01-02", "01/02/2006", "02/01/2006", "2 Jan 2006"},
+		fields: map[string]string{
+			"date":                "date",
+			"flightdate":          "date",
+			"from":                "departure.place",
+			"to":                  "arrival.place",
+			"out":                 "departure.time",
+			"actualdeparturetime": "departure.time",
+			"in":                  "arrival.time",
+			"actualarrivaltime":   "arrival.time",
+			"aircraftid":          "aircraft.reg",
+			"aircrafttype":        "aircraft.model",
+			"enginetype":          "aircraft.class",
+			"multipilot":          "time.mcc",
+			"totaltime":           "time.total",
+			"pic":                 "time.pic",
+			"sic":                 "time.copilot",
+			"night":               "time.night",
+			"actualinstrument":    "time.ifr",
+			"simulatedinstrument": "time.ifr",
+			"dualreceived":        "time.dual",
+			"dualgiven":           "time.instructor",
+			"cfi":                 "time.instructor",
+			"simulator":           "sim.time",
+			"simulatortype":       "sim.name",
+			"daylandings":         "landings.day",
+			"nightlandings":       "landings.night",
+			"picp1crew":           "pic",
+			"remarks":             "remarks",
+		},
+	},
+	"mccpilotlog": {
+		dateLayouts: []string{"2006-01-02", "02/01/2006"},
+		minutes:     true,
+		fields: map[string]string{
+			"mccdate":        "date",
+			"afdep":          "departure.place",
+			"timedep":        "departure.time",
+			"afarr":          "arrival.place",
+			"timearr":        "arrival.time",
+			"acmodel":        "aircraft.model",
+			"acreg":          "aircraft.reg",
+			"acissim":        "sim",
+			"timemp":         "time.mcc",
+			"timetotal":      "time.total",
+			"timetotalsim":   "sim.time",
+			"timepic":        "time.pic",
+			"timepicus":      "time.pic",
+			"timesic":        "time.copilot",
+			"timedual":       "time.dual",
+			"timeinstructor": "time.instructor",
+			"timenight":      "time.night",
+			"timeifr":        "time.ifr",
+			"ldgday":         "landings.day",
+			"ldgnight":       "landings.night",
+			"pilot1name":     "pic",
+			"remarks":        "remarks",
+		},
+	},
+}
+
+// importRecord is a logbook record with some extra fields of the csv row
+// which are used to fill the logbook columns
+type importRecord struct {
+	record   logbookRecord
+	class    string
+	landings int
+	isSim    bool
+	date     time.Time
+}
+
+// normalizeFieldName returns the csv field name in lower case without spaces and punctuation
+func normalizeFieldName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// parseImportDuration parses duration fields of the csv exports.
+// It can be "H:MM", decimal hours or minutes
+func parseImportDuration(value string, minutes bool) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if strings.Contains(value, ":") {
+		parts := strings.SplitN(value, ":", 2)
+		h, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, fmt.Errorf("wrong time format %s", value)
+		}
+		m, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, fmt.Errorf("wrong time format %s", value)
+		}
+		return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+	}
+
+	num, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("wrong time format %s", value)
+	}
+
+	if minutes {
+		return time.Duration(math.Round(num)) * time.Minute, nil
+	}
+	return time.Duration(math.Round(num*60)) * time.Minute, nil
+}
+
+// parseImportClock converts time of the day ("14:10", "1410", "14:10:00") to the logbook format "1410"
+func parseImportClock(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	// some exports contain full date and time
+	if i := strings.LastIndex(value, " "); i != -1 {
+		value = value[i+1:]
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) == 1 && len(value) >= 3 && len(value) <= 4 {
+		parts = []string{value[:len(value)-2], value[len(value)-2:]}
+	}
+	if len(parts) < 2 {
+		return "", fmt.Errorf("wrong time of the day format %s", value)
+	}
+
+	h, err := strconv.Atoi(parts[0])
+	if err != nil || h > 23 {
+		return "", fmt.Errorf("wrong time of the day format %s", value)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m > 59 {
+		return "", fmt.Errorf("wrong time of the day format %s", value)
+	}
+
+	return fmt.Sprintf("%02d%02d", h, m), nil
+}
+
+// parseImportDate parses the date field with one of the format layouts
+func parseImportDate(value string, layouts []string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("wrong date format %s", value)
+}
+
+// parseImportClass returns SE or ME for the engine class/type field
+func parseImportClass(value string) string {
+	value = normalizeFieldName(value)
+
+	if strings.Contains(value, "multi") || strings.HasPrefix(value, "ame") || value == "me" {
+		return "ME"
+	} else if strings.Contains(value, "single") || strings.HasPrefix(value, "ase") || value == "se" {
+		return "SE"
+	}
+	return ""
+}
+
+// parseImportBool parses the boolean fields of the csv exports
+func parseImportBool(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "y", "x":
+		return true
+	}
+	return false
+}
+
+// readImportCSV reads the csv file and returns the flights table and the aircraft table (if any).
+// ForeFlight exports contain several tables in one file, which are started with
+// "Aircraft Table" and "Flights Table" lines
+func readImportCSV(r io.Reader) (flights [][]string, aircraft [][]string, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	// LogTen exports are tab delimited
+	firstLine := strings.SplitN(string(data), "\n", 2)[0]
+	if strings.Contains(firstLine, "\t") && !strings.Contains(firstLine, ",") {
+		reader.Comma = '\t'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := &flights
+	for _, row := range rows {
+		switch strings.TrimSpace(row[0]) {
+		case "Aircraft Table":
+			table = &aircraft
+			continue
+		case "Flights Table":
+			table = &flights
+			*table = nil
+			continue
+		}
+		*table = append(*table, row)
+	}
+
+	return flights, aircraft, nil
+}
+
+// parseImportAircraft returns engine class of the aircraft from the ForeFlight aircraft table
+func parseImportAircraft(table [][]string) (models map[string]string, classes map[string]string) {
+	models = make(map[string]string)
+	classes = make(map[string]string)
+
+	if len(table) == 0 {
+		return models, classes
+	}
+
+	columns := make(map[string]int)
+	for i, name := range table[0] {
+		columns[normalizeFieldName(name)] = i
+	}
+
+	get := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	for _, row := range table[1:] {
+		reg := get(row, "aircraftid")
+		if reg == "" {
+			continue
+		}
+
+		model := get(row, "typecode")
+		if model == "" {
+			model = get(row, "model")
+		}
+		models[reg] = model
+		classes[reg] = parseImportClass(get(row, "class"))
+	}
+
+	return models, classes
+}
+
+// parseImportRow converts the csv row to the logbook record
+//
+// format importFormat - import format description
+//
+// header []string - mapped logbook fields for each csv column
+//
+// row []string - csv row
+func parseImportRow(format importFormat, header []string, row []string) (importRecord, error) {
+	var ir importRecord
+	record := &ir.record
+
+	for i, value := range row {
+		value = strings.TrimSpace(value)
+		if i >= len(header) || header[i] == "" || value == "" {
+			continue
+		}
+
+		field := header[i]
+
+		switch field {
+		case "date":
+			date, err := parseImportDate(value, format.dateLayouts)
+			if err != nil {
+				return ir, err
+			}
+			ir.date = date
+			record.date = date.Format(dateLayout)
+
+		case "departure.place":
+			record.departure.place = strings.ToUpper(value)
+		case "arrival.place":
+			record.arrival.place = strings.ToUpper(value)
+
+		case "departure.time", "arrival.time":
+			clock, err := parseImportClock(value)
+			if err != nil {
+				return ir, err
+			}
+			if field == "departure.time" {
+				record.departure.time = clock
+			} else {
+				record.arrival.time = clock
+			}
+
+		case "aircraft.model":
+			record.aircraft.model = value
+		case "aircraft.reg":
+			record.aircraft.reg = strings.ToUpper(value)
+		case "aircraft.class":
+			ir.class = parseImportClass(value)
+
+		case "sim":
+			ir.isSim = parseImportBool(value)
+		case "sim.name":
+			record.sim.name = value
+
+		case "pic":
+			record.pic = value
+		case "person":
+			// ForeFlight crew field is "Name;Role;Email"
+			person := strings.Split(value, ";")
+			if len(person) > 1 && strings.EqualFold(strings.TrimSpace(person[1]), "PIC") {
+				record.pic = strings.TrimSpace(person[0])
+			}
+		case "remarks":
+			record.remarks = value
+
+		case "landings.day", "landings.night", "landings.all":
+			num, err := strconv.Atoi(value)
+			if err != nil {
+				return ir, fmt.Errorf("wrong landings format %s", value)
+			}
+			if field == "landings.day" {
+				record.landings.day += num
+			} else if field == "landings.night" {
+				record.landings.night += num
+			} else {
+				ir.landings += num
+			}
+
+		default:
+			// the rest are durations, some of them are summed (e.g. actual and simulated instrument)
+			duration, err := parseImportDuration(value, format.minutes)
+			if err != nil {
+				return ir, err
+			}
+
+			switch field {
+			case "time.total":
+				record.time.total.time += duration
+			case "time.mcc":
+				record.time.mcc.time += duration
+			case "time.pic":
+				record.time.pic.time += duration
+			case "time.copilot":
+				record.time.copilot.time += duration
+			case "time.night":
+				record.time.night.time += duration
+			case "time.ifr":
+				record.time.ifr.time += duration
+			case "time.dual":
+				record.time.dual.time += duration
+			case "time.instructor":
+				record.time.instructor.time += duration
+			case "sim.time":
+				record.sim.time.time += duration
+			}
+		}
+	}
+
+	if record.date == "" {
+		return ir, fmt.Errorf("date is not set")
+	}
+
+	return ir, nil
+}
+
+// fillImportRecord fills the logbook columns which don't exist in other applications
+// (single/multi engine and multi pilot times, simulator sessions, landings)
+func fillImportRecord(ir *importRecord) {
+	record := &ir.record
+
+	// simulator session
+	if ir.isSim && record.sim.time.time == 0 {
+		record.sim.time = record.time.total
+	}
+	if ir.isSim || (record.sim.time.time != 0 && record.time.total.time == 0) {
+		if record.sim.name == "" {
+			record.sim.name = record.aircraft.model
+		}
+		record.departure = location{}
+		record.arrival = location{}
+		record.aircraft.model = ""
+		record.aircraft.reg = ""
+		record.time = times{}
+		return
+	}
+
+	// all landings without split to day and night
+	if ir.landings > record.landings.day+record.landings.night {
+		record.landings.day = ir.landings - record.landings.night
+	}
+
+	// multi pilot time goes to MCC column only, otherwise it depends on engines
+	if record.time.mcc.time == 0 && ir.class == "ME" && record.time.copilot.time != 0 {
+		record.time.mcc = record.time.total
+	}
+	if record.time.mcc.time == 0 {
+		if ir.class == "ME" {
+			record.time.me = record.time.total
+		} else if ir.class == "SE" {
+			record.time.se = record.time.total
+		}
+	}
+
+	if record.pic == "" && record.time.pic.time != 0 {
+		record.pic = "Self"
+	}
+}
+
+// saveImportXLSX creates a new xlsx file with the "Flights" sheet
+//
+// fileName string - output file name
+//
+// records []logbookRecord - logbook records in the order they will be written
+func saveImportXLSX(fileName string, records []logbookRecord) error {
+	xls := excelize.NewFile()
+	xls.SetSheetName(xls.GetSheetName(0), sheetName)
+
+	if err := xls.SetSheetRow(sheetName, "A1", &importHeader1); err != nil {
+		return err
+	}
+	if err := xls.SetSheetRow(sheetName, "A2", &importHeader2); err != nil {
+		return err
+	}
+
+	style, err := xls.NewStyle(`{"font":{"bold":true},"alignment":{"horizontal":"center"}}`)
+	if err != nil {
+		return err
+	}
+	if err := xls.SetCellStyle(sheetName, "A1", "W2", style); err != nil {
+		return err
+	}
+
+	timeStyle, err := newTimeStyle(xls)
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		if err := setXLSXRow(xls, sheetName, importStartRow+i, formatRecord(record), allColumns(), timeStyle); err != nil {
+			return err
+		}
+	}
+
+	return xls.SaveAs(fileName)
+}
+
+// Import converts the csv export of the other logbook applications
+// (ForeFlight, LogTen Pro, mccPILOTLOG) to the xlsx file with "Flights" sheet
+func Import(logbookConfig LogbookConfig) {
+
+	format, ok := importFormats[logbookConfig.ImportFormat]
+	if !ok {
+		log.Fatalf("Unknown import format %s", logbookConfig.ImportFormat)
+	}
+
+	file, err := os.Open(logbookConfig.ImportFile)
+	if err != nil {
+		log.Fatalf("Cannot open file for import: %v", err)
+	}
+	defer file.Close()
+
+	flights, aircraft, err := readImportCSV(file)
+	if err != nil {
+		log.Fatalf("Cannot read csv file: %v", err)
+	}
+
+	if len(flights) == 0 {
+		log.Fatalf("No flights found in the %s", logbookConfig.ImportFile)
+	}
+
+	models, classes := parseImportAircraft(aircraft)
+
+	// map csv fields to the logbook fields
+	var unmapped []string
+	header := make([]string, len(flights[0]))
+	for i, name := range flights[0] {
+		if field, ok := format.fields[normalizeFieldName(name)]; ok {
+			header[i] = field
+		} else if strings.TrimSpace(name) != "" {
+			unmapped = append(unmapped, name)
+		}
+	}
+
+	var records []importRecord
+	var skipped []string
+
+	for i, row := range flights[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		ir, err := parseImportRow(format, header, row)
+		if err != nil {
+			// +2 because of the header and 1-based numbering
+			skipped = append(skipped, fmt.Sprintf("row %d: %v", i+2, err))
+			continue
+		}
+
+		reg := ir.record.aircraft.reg
+		if ir.record.aircraft.model == "" {
+			ir.record.aircraft.model = models[reg]
+		}
+		if ir.class == "" {
+			ir.class = classes[reg]
+		}
+
+		fillImportRecord(&ir)
+		records = append(records, ir)
+	}
+
+	// the logbook is sorted by date and departure time
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].date.Equal(records[j].date) {
+			return records[i].record.departure.time < records[j].record.departure.time
+		}
+		return records[i].date.Before(records[j].date)
+	})
+
+	var logbookRecords []logbookRecord
+	for _, ir := range records {
+		logbookRecords = append(logbookRecords, ir.record)
+	}
+
+	if logbookConfig.Reverse {
+		for i, j := 0, len(logbookRecords)-1; i < j; i, j = i+1, j-1 {
+			logbookRecords[i], logbookRecords[j] = logbookRecords[j], logbookRecords[i]
+		}
+	}
+
+	if err := saveImportXLSX(logbookConfig.OutputFile, logbookRecords); err != nil {
+		log.Fatalf("Cannot save xlsx file: %v", err)
+	}
+
+	if len(unmapped) > 0 {
+		fmt.Printf("Unmapped fields (%d): %s\n", len(unmapped), strings.Join(unmapped, ", "))
+	}
+
+	if len(skipped) > 0 {
+		fmt.Printf("Rows which couldn't be converted (%d):\n", len(skipped))
+		for _, line := range skipped {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	fmt.Printf("%d records have been imported to %s, the first record is in the row %d\n", len(logbookRecords), logbookConfig.OutputFile, importStartRow)
+}
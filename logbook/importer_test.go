@@ -0,0 +1,83 @@
+package logbook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestParseImportDuration(t *testing.T) {
+	d, _ := parseImportDuration("1.5", false)
+	assert.Equal(t, d, 90*time.Minute)
+
+	d, _ = parseImportDuration("2:05", false)
+	assert.Equal(t, d, 125*time.Minute)
+
+	d, _ = parseImportDuration("95", true)
+	assert.Equal(t, d, 95*time.Minute)
+
+	_, err := parseImportDuration("abc", false)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestParseImportClock(t *testing.T) {
+	clock, _ := parseImportClock("14:10")
+	assert.Equal(t, clock, "1410")
+
+	clock, _ = parseImportClock("930")
+	assert.Equal(t, clock, "0930")
+
+	clock, _ = parseImportClock("2021-10-08 07:05:00")
+	assert.Equal(t, clock, "0705")
+
+	_, err := parseImportClock("25:00")
+	assert.Equal(t, err != nil, true)
+}
+
+func TestImportForeFlight(t *testing.T) {
+	data := `ForeFlight Logbook Import,,,,,,,,
+,,,,,,,,
+Aircraft Table,,,,,,,,
+AircraftID,TypeCode,Model,Class,,,,,
+OK-NXX,L200,Morava,AMEL,,,,,
+,,,,,,,,
+Flights Table,,,,,,,,
+Date,AircraftID,From,To,TimeOut,TimeIn,TotalTime,PIC,AllLandings,HobbsStart
+2021-10-08,OK-NXX,lksz,lkpr,14:10,15:40,1.5,1.5,2,100.1
+08.10.2021,OK-NXX,LKSZ,LKPR,14:10,15:40,1.5,1.5,2,100.1
+`
+	flights, aircraft, err := readImportCSV(strings.NewReader(data))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(flights), 3)
+	assert.Equal(t, len(aircraft), 3)
+
+	models, classes := parseImportAircraft(aircraft)
+	assert.Equal(t, models["OK-NXX"], "L200")
+	assert.Equal(t, classes["OK-NXX"], "ME")
+
+	format := importFormats["foreflight"]
+	header := make([]string, len(flights[0]))
+	for i, name := range flights[0] {
+		header[i] = format.fields[normalizeFieldName(name)]
+	}
+	assert.Equal(t, header[9], "")
+
+	ir, err := parseImportRow(format, header, flights[1])
+	assert.Equal(t, err, nil)
+	ir.class = classes[ir.record.aircraft.reg]
+	fillImportRecord(&ir)
+
+	row := formatRecord(ir.record)
+	assert.Equal(t, row[0], "08/10/2021")
+	assert.Equal(t, row[1], "LKSZ")
+	assert.Equal(t, row[2], "1410")
+	assert.Equal(t, row[8], "1:30")
+	assert.Equal(t, row[10], "1:30")
+	assert.Equal(t, row[11], "2")
+	assert.Equal(t, row[21], "Self")
+
+	_, err = parseImportRow(format, header, flights[2])
+	assert.Equal(t, err != nil, true)
+}
@@ -1,36 +1,78 @@
 package logbook
 
 import (
-	"context"
 	"embed"
-	"encoding/json"
 	"fmt"
-	"image/color"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 
-	sm "github.com/flopp/go-staticmaps"
-	"github.com/fogleman/gg"
-	"github.com/golang/geo/s2"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/xuri/excelize/v2"
-	"google.golang.org/api/option"
-	"google.golang.org/api/sheets/v4"
 )
 
 type LogbookConfig struct {
-	SourceType     string
-	FileName       string
-	APIKey         string
-	SpreadsheetID  string
-	StartRow       int
-	LogbookOwner   string
-	PageBrakes     []string
-	Reverse        bool
-	FilterNoRoutes bool
-	FilterDate     string
+	SourceType      string
+	FileName        string
+	APIKey          string
+	SpreadsheetID   string
+	StartRow        int
+	LogbookOwner    string
+	PageBrakes      []string
+	Reverse         bool
+	FilterNoRoutes  bool
+	FilterDate      string
+	ImportFormat    string
+	ImportFile      string
+	OutputFile      string
+	Row             int
+	Record          map[string]string
+	CacheDir        string
+	GoogleEndpoint  string
+	GoogleAuth      string
+	CredentialsFile string
+	TokenFile       string
+	SheetName       string
+	Sources         []SourceConfig
+	FillNight       bool
+	FillTimes       bool
+	CheckTolerance  time.Duration
+	AircraftTypes   map[string]AircraftType
+	Aircraft        []Aircraft
+	AircraftSheet   string
+	AirportsDB      string
+	CustomAirports  string
+	ShowDistances   bool
+	ShowRegions     bool
+	MapLegend       bool
+	MapColorBy      string
+	MapRouteWeight  bool
+	TileURL         string
+	TileAttribution string
+	TileShards      []string
+	TileSize        int
+	MBTilesFile     string
+	MapWidth        int
+	MapHeight       int
+	MapDPI          float64
+	ExportMap       bool
+	MapLabels       string
+	MapMarkerScale  bool
+	MapPadding      int
+	HomeBase        string
+	MapTimeline     string
+	MapFrameDelay   int
+	MapHeatmap      bool
+	HeatmapRadius   float64
+	HeatmapColors   []string
+	MapCountries    string
+	ServeAddress    string
+	APITokens       []string
+	WatchPDF        string
+	WatchMap        string
+	WatchStats      string
+	WatchDelay      int
 }
 
 // logbook time type, sort of a wrapper for time.Duration
@@ -38,16 +80,19 @@ type logbookTime struct {
 	time time.Duration
 }
 
-func (t *logbookTime) SetTime(strTime string) {
-	var err error
-
+// parseLogbookTime parses the logbook time in H:MM format
+func parseLogbookTime(strTime string) (time.Duration, error) {
 	if strTime == "" {
 		strTime = "0:0"
 	}
 
-	strTime = fmt.Sprintf("%sm", strings.ReplaceAll(strTime, ":", "h"))
+	return time.ParseDuration(fmt.Sprintf("%sm", strings.ReplaceAll(strTime, ":", "h")))
+}
+
+func (t *logbookTime) SetTime(strTime string) {
+	var err error
 
-	t.time, err = time.ParseDuration(strTime)
+	t.time, err = parseLogbookTime(strTime)
 	if err != nil {
 		fmt.Printf("Error parsing time %s", strTime)
 		t.time, _ = time.ParseDuration("0h0m")
@@ -138,6 +183,11 @@ var bodyRowHeight = 5.0
 var footerRowHeight = 6.0
 
 var sheetName = "Flights"
+var dateLayout = "02/01/2006"
+
+// columns of the logbook row with time and landings values
+var timeColumns = []int{7, 8, 9, 10, 13, 14, 15, 16, 17, 18, 20}
+var landingsColumns = []int{11, 12}
 
 var header1 = []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}
 var header2 = []string{"DATE", "DEPARTURE", "ARRIVAL", "AIRCRAFT", "SINGLE PILOT TIME", "MULTI PILOT TIME", "TOTAL TIME", "PIC NAME", "LANDINGS", "OPERATIONAL CONDITION TIME", "PILOT FUNCTION TIME", "FSTD SESSION", "REMARKS AND ENDORSMENTS"}
@@ -151,29 +201,47 @@ var w4 = []float64{20.45, 47.65, 11.2, 11.2, 11.2, 11.2, 22.86, 8.38, 8.38, 11.2
 //go:embed  db/airports.json font/*
 var content embed.FS
 
-// getLogbookDump reads the logbook from the source (google spreadsheet, local xlsx file)
+// sheet returns the name of the sheet with logbook records
+func (logbookConfig LogbookConfig) sheet() string {
+	if logbookConfig.SheetName != "" {
+		return logbookConfig.SheetName
+	}
+	return sheetName
+}
+
+// getLogbookDump reads the logbook from the configured source or merges several sources
 func getLogbookDump(logbookConfig LogbookConfig) (values [][]interface{}, err error) {
+	if len(logbookConfig.Sources) > 0 {
+		return getMergedDump(logbookConfig)
+	}
+
+	return getSourceDump(logbookConfig)
+}
+
+// getSourceDump reads the logbook from the source (google spreadsheet, local xlsx or csv file, local database)
+func getSourceDump(logbookConfig LogbookConfig) (values [][]interface{}, err error) {
 
 	if logbookConfig.SourceType == "google" {
 		// get data from google spreadsheet
-		ctx := context.Background()
-
-		srv, err := sheets.NewService(ctx, option.WithAPIKey(logbookConfig.APIKey))
+		values, err = getGoogleDump(logbookConfig)
 		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve Google Spreadsheets client: %v", err)
+			return nil, err
 		}
 
-		response, err := srv.Spreadsheets.Values.Get(logbookConfig.SpreadsheetID, fmt.Sprintf("%s!A%d:W", sheetName, logbookConfig.StartRow)).Do()
+	} else if logbookConfig.SourceType == "csv" {
+		// get data from local csv file
+		values, err = getCSVDump(logbookConfig)
 		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve data from sheet: %v", err)
+			return nil, err
 		}
 
-		if len(response.Values) == 0 {
-			return nil, fmt.Errorf("no data found in the sheet")
+	} else if logbookConfig.SourceType == "db" {
+		// get data from local database
+		values, err = getDBDump(logbookConfig)
+		if err != nil {
+			return nil, err
 		}
 
-		values = response.Values
-
 	} else {
 		// get data from local xlsx file
 		xls, err := excelize.OpenFile(logbookConfig.FileName)
@@ -181,7 +249,7 @@ func getLogbookDump(logbookConfig LogbookConfig) (values [][]interface{}, err er
 			return nil, fmt.Errorf("error opening xlsx file: %v", err)
 		}
 
-		rows, err := xls.GetRows(sheetName)
+		rows, err := xls.GetRows(logbookConfig.sheet())
 		if err != nil {
 			return nil, fmt.Errorf("unable to retrieve data from sheet: %v", err)
 		}
@@ -195,11 +263,7 @@ func getLogbookDump(logbookConfig LogbookConfig) (values [][]interface{}, err er
 			var appendRow []interface{}
 
 			for _, colCell := range row {
-				if strings.HasPrefix(colCell, ":") {
-					// somehow in case the time equals 0:mm it returns :mm only
-					colCell = "0" + colCell
-				}
-				appendRow = append(appendRow, colCell)
+				appendRow = append(appendRow, normalizeXLSXCell(colCell))
 			}
 
 			values = append(values, appendRow)
@@ -211,12 +275,26 @@ func getLogbookDump(logbookConfig LogbookConfig) (values [][]interface{}, err er
 
 }
 
+// normalizeXLSXCell fixes the cell value returned by excelize
+func normalizeXLSXCell(colCell string) string {
+	if strings.HasPrefix(colCell, ":") {
+		// somehow in case the time equals 0:mm it returns :mm only
+		colCell = "0" + colCell
+	}
+	return colCell
+}
+
 // parseRecord returns a formed and parsed logbookRecord
 //
 // row []interface{} - element in *sheets.ValueRange from getLogbookDump function
 func parseRecord(row []interface{}) logbookRecord {
 	var record logbookRecord
 
+	// the last empty cells are not returned by the sources
+	for len(row) < len(header3) {
+		row = append(row, "")
+	}
+
 	record.date = row[0].(string)
 	record.departure.place = row[1].(string)
 	record.departure.time = row[2].(string)
@@ -243,13 +321,104 @@ func parseRecord(row []interface{}) logbookRecord {
 	record.sim.name = row[19].(string)
 	record.sim.time.SetTime(row[20].(string))
 	record.pic = row[21].(string)
-	if len(row) > 22 {
-		record.remarks = row[22].(string)
-	}
+	record.remarks = row[22].(string)
 
 	return record
 }
 
+// validateRow checks the logbook row fields have proper formats
+//
+// row []string - logbook row in the 23 columns layout
+func validateRow(row []string) error {
+	if _, err := time.Parse(dateLayout, row[0]); err != nil {
+		return fmt.Errorf("wrong date %s, should be DD/MM/YYYY", row[0])
+	}
+
+	for _, i := range []int{2, 4} {
+		if row[i] == "" {
+			continue
+		}
+		if _, err := time.Parse("1504", row[i]); err != nil || len(row[i]) != 4 {
+			return fmt.Errorf("wrong time %s, should be HHMM", row[i])
+		}
+	}
+
+	for _, i := range timeColumns {
+		if row[i] == "" {
+			continue
+		}
+		parts := strings.Split(row[i], ":")
+		if len(parts) != 2 || len(parts[1]) != 2 {
+			return fmt.Errorf("wrong time %s, should be H:MM", row[i])
+		}
+		if _, err := parseLogbookTime(row[i]); err != nil {
+			return fmt.Errorf("wrong time %s, should be H:MM", row[i])
+		}
+	}
+
+	for _, i := range landingsColumns {
+		if row[i] == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(row[i]); err != nil {
+			return fmt.Errorf("wrong number of landings %s", row[i])
+		}
+	}
+
+	// it's either flight or simulator session
+	if row[19] == "" && row[20] == "" {
+		if row[1] == "" || row[3] == "" {
+			return fmt.Errorf("departure and arrival places should be set")
+		}
+		if row[10] == "" {
+			return fmt.Errorf("total time should be set")
+		}
+	} else if row[19] == "" || row[20] == "" {
+		return fmt.Errorf("simulator type and time should be set")
+	}
+
+	return nil
+}
+
+// formatRecord returns a logbook row in the same 23 columns layout
+// as the "Flights" sheet, it's an opposite function for parseRecord
+//
+// record logbookRecord - logbook record
+func formatRecord(record logbookRecord) []string {
+	landings := func(num int) string {
+		if num == 0 {
+			return ""
+		}
+		return strconv.Itoa(num)
+	}
+
+	return []string{
+		record.date,
+		record.departure.place,
+		record.departure.time,
+		record.arrival.place,
+		record.arrival.time,
+		record.aircraft.model,
+		record.aircraft.reg,
+		record.time.se.GetTime(),
+		record.time.me.GetTime(),
+		record.time.mcc.GetTime(),
+		record.time.total.GetTime(),
+		landings(record.landings.day),
+		landings(record.landings.night),
+		record.time.night.GetTime(),
+		record.time.ifr.GetTime(),
+		record.time.pic.GetTime(),
+		record.time.copilot.GetTime(),
+		record.time.dual.GetTime(),
+		record.time.instructor.GetTime(),
+		record.sim.name,
+		record.sim.time.GetTime(),
+		record.pic,
+		record.remarks,
+	}
+}
+
 // calculateTotals sums the provided logbookTotalRecord variable with logbook record.
 // This is sort of append function for the custom type
 //
@@ -467,14 +636,16 @@ func LoadFonts(pdf *gofpdf.Fpdf) {
 
 }
 
-// Export reads the google spreadsheet and create pdf with logbook in EASA format
-func Export(logbookConfig LogbookConfig) {
+// exportPDF creates the pdf with the logbook in EASA format
+//
+// logbookConfig LogbookConfig - logbook config with the export settings
+//
+// response [][]interface{} - logbook dump
+func exportPDF(logbookConfig LogbookConfig, response [][]interface{}) (*gofpdf.Fpdf, error) {
+	var err error
 
-	// get data from the google spreadsheet
-	response, err := getLogbookDump(logbookConfig)
-	if err != nil {
-		log.Fatalf("Cannot get logbook dump: %v", err)
-	}
+	// the page brakes are removed while printing, the config can be used for the next export
+	logbookConfig.PageBrakes = append([]string(nil), logbookConfig.PageBrakes...)
 
 	// start forming the pdf file
 	pdf := gofpdf.New("L", "mm", "A4", "")
@@ -495,10 +666,32 @@ func Export(logbookConfig LogbookConfig) {
 
 	fill := false
 
+	var registry aircraftRegistry
+	if logbookConfig.FillTimes {
+		registry, err = loadAircraftRegistry(logbookConfig)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load aircraft registry: %v", err)
+		}
+	}
+
+	var airports airportsDB
+	if logbookConfig.FillNight {
+		airports, err = loadAirportsDB(logbookConfig)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load airports database: %v", err)
+		}
+	}
+
 	logBookRow := func(item int) {
 		rowCounter += 1
 
 		record := parseRecord(response[item])
+		if logbookConfig.FillTimes {
+			fillFlightTimes(&record, registry)
+		}
+		if logbookConfig.FillNight {
+			fillNightTime(&record, airports)
+		}
 
 		totalPage = calculateTotals(totalPage, record)
 		totalTime = calculateTotals(totalTime, record)
@@ -556,45 +749,46 @@ func Export(logbookConfig LogbookConfig) {
 	pdf.SetY(pdf.GetY() - 1)
 	pdf.CellFormat(0, 10, fmt.Sprintf("page %d", pageCounter), "", 0, "L", false, 0, "")
 
-	// save and close pdf
-	err = pdf.OutputFileAndClose("logbook.pdf")
-	if err != nil {
-		log.Fatalf("Cannot export pdf: %v\n", err)
-	} else {
-		fmt.Println("Loogbook has been exported to logbook.pdf")
+	// the map of all flights on the last page
+	if logbookConfig.ExportMap {
+		if err := addMapPage(pdf, logbookConfig, response); err != nil {
+			return nil, fmt.Errorf("cannot add map to pdf: %v", err)
+		}
 	}
-}
 
-// loadAirportsDB loads the airports data (location and so on)
-func loadAirportsDB() (map[string]interface{}, error) {
-	var airports map[string]interface{}
+	return pdf, nil
+}
 
-	byteValue, err := content.ReadFile("db/airports.json")
+// Export reads the google spreadsheet and create pdf with logbook in EASA format
+func Export(logbookConfig LogbookConfig) {
 
+	// get data from the google spreadsheet
+	response, err := getLogbookDump(logbookConfig)
 	if err != nil {
-		return airports, err
+		log.Fatalf("Cannot get logbook dump: %v", err)
 	}
 
-	err = json.Unmarshal([]byte(byteValue), &airports)
+	pdf, err := exportPDF(logbookConfig, response)
 	if err != nil {
-		return nil, err
+		log.Fatalf("Cannot export pdf: %v\n", err)
 	}
 
-	return airports, nil
+	// save and close pdf
+	err = pdf.OutputFileAndClose("logbook.pdf")
+	if err != nil {
+		log.Fatalf("Cannot export pdf: %v\n", err)
+	} else {
+		fmt.Println("Loogbook has been exported to logbook.pdf")
+	}
 }
 
-// RendersMap generates a PNG file with airports markers and routes between them
+// RendersMap generates a PNG, SVG or PDF file with airports markers and routes between them
 func RendersMap(logbookConfig LogbookConfig) {
 
-	airportMarkers := make(map[string]struct{})
-	routeLines := make(map[string]struct{})
-
-	var totals logbookTotalRecord
-
-	// load airports.json
-	airports, err := loadAirportsDB()
+	// load airports database
+	airports, err := loadAirportsDB(logbookConfig)
 	if err != nil {
-		log.Fatalf("Cannot load airports.json file: %v", err)
+		log.Fatalf("Cannot load airports database: %v", err)
 	}
 
 	// get data from the google spreadsheet
@@ -603,80 +797,53 @@ func RendersMap(logbookConfig LogbookConfig) {
 		log.Fatalf("Cannot get logbook dump: %v", err)
 	}
 
-	// parsing
-	for _, row := range response {
-		record := parseRecord(row)
-
-		if (logbookConfig.FilterDate != "" && strings.Contains(record.date, logbookConfig.FilterDate)) || logbookConfig.FilterDate == "" {
-			// add to the list of the airport markers departure and arrival
-			// it will be automatically a list of unique airports
-			airportMarkers[record.departure.place] = struct{}{}
-			airportMarkers[record.arrival.place] = struct{}{}
+	rows := filterRowsByDate(response, logbookConfig.FilterDate)
 
-			// the same for the route lines
-			if !logbookConfig.FilterNoRoutes {
-				if record.departure.place != record.arrival.place {
-					routeLines[fmt.Sprintf("%s-%s", record.departure.place, record.arrival.place)] = struct{}{}
-				}
-			}
+	fileName := logbookConfig.OutputFile
+	if fileName == "" {
+		fileName = "map.png"
+	}
 
-			totals = calculateTotals(totals, record)
+	// animated map with one frame per period
+	if logbookConfig.MapTimeline != "" {
+		frames, err := renderMapTimeline(fileName, logbookConfig, rows, airports)
+		if err != nil {
+			log.Fatalf("Cannot render a map timeline %v", err)
 		}
 
+		fmt.Printf("Map timeline with %d frames has been saved to %s\n", frames, fileName)
+		return
 	}
 
-	fmt.Printf("Airports: %d\n", len(airportMarkers))
-	fmt.Printf("Routes: %d\n", len(routeLines))
-	fmt.Printf("Total time: %s\n", totals.time.total.GetTime())
-	fmt.Printf("Landings: %d day, %d night\n", totals.landings.day, totals.landings.night)
-
-	ctx := sm.NewContext()
-	ctx.SetSize(1920, 1080)
-
-	// generate routes lines
-	for route := range routeLines {
-		places := strings.Split(route, "-")
+	scene, err := buildMapScene(logbookConfig, rows, airports)
+	if err != nil {
+		log.Fatalf("Cannot render a map %v", err)
+	}
 
-		if airport1, ok := airports[places[0]].(map[string]interface{}); ok {
-			if airport2, ok := airports[places[1]].(map[string]interface{}); ok {
+	fmt.Printf("Airports: %d\n", scene.airports)
+	fmt.Printf("Routes: %d\n", scene.routes)
+	fmt.Printf("Total time: %s\n", scene.totals.time.total.GetTime())
+	fmt.Printf("Landings: %d day, %d night\n", scene.totals.landings.day, scene.totals.landings.night)
 
-				ctx.AddObject(
-					sm.NewPath(
-						[]s2.LatLng{
-							s2.LatLngFromDegrees(airport1["lat"].(float64), airport1["lon"].(float64)),
-							s2.LatLngFromDegrees(airport2["lat"].(float64), airport2["lon"].(float64)),
-						},
-						color.Black,
-						0.5),
-				)
+	if logbookConfig.MapCountries != "" {
+		fmt.Printf("Countries: %d\n", scene.countries)
 
-			}
+		if missing := scene.missingCountries(); len(missing) > 0 {
+			fmt.Printf("Cannot shade countries (not found in the countries file): %s\n", strings.Join(missing, ", "))
 		}
 	}
 
-	// generate airports markers
-	for place := range airportMarkers {
-
-		if airport, ok := airports[place].(map[string]interface{}); ok {
-			ctx.AddObject(
-				sm.NewMarker(
-					s2.LatLngFromDegrees(airport["lat"].(float64), airport["lon"].(float64)),
-					color.RGBA{0xff, 0, 0, 0xff},
-					16.0,
-				),
-			)
-		}
-
+	if scene.hiddenLabels > 0 {
+		fmt.Printf("%d airport labels are hidden to avoid overlapping\n", scene.hiddenLabels)
 	}
 
-	img, err := ctx.Render()
-	if err != nil {
-		log.Fatalf("Cannot render a map %v", err)
+	if len(scene.missingAirports) > 0 {
+		fmt.Printf("Cannot place airports (add them to the custom airports file): %s\n", strings.Join(scene.missingAirports, ", "))
 	}
 
-	if err := gg.SavePNG("map.png", img); err != nil {
+	if err := saveMap(fileName, scene, logbookConfig); err != nil {
 		log.Fatalf("Cannot save a map %v", err)
 	} else {
-		fmt.Printf("Map has been saved to map.png\n")
+		fmt.Printf("Map has been saved to %s\n", fileName)
 	}
 }
@@ -0,0 +1,286 @@
+package logbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	sm "github.com/flopp/go-staticmaps"
+	"github.com/fogleman/gg"
+	"github.com/golang/geo/s2"
+)
+
+// default colour ramp of the visited countries from the least to the most flown
+var defaultCountryColors = []string{"#c6dbef", "#08519c"}
+
+// opacity of the visited countries
+var countryAlpha = 0.5
+
+// properties of the countries GeoJSON features with the ISO 3166-1 alpha-2 country code, the
+// first one found is used
+var countryCodeProperties = []string{"ISO_A2_EH", "ISO_A2", "iso_a2", "ISO3166-1-Alpha-2", "iso_3166_1_alpha_2"}
+
+// latitude limit of the web mercator projection
+var maxMercatorLat = 85.0511
+
+// countryShape is the country boundary from the countries GeoJSON file
+type countryShape struct {
+	code  string
+	rings [][]s2.LatLng
+}
+
+// shadedCountry is the visited country with its boundary in pixels
+type shadedCountry struct {
+	rings [][][2]float64
+	color color.Color
+}
+
+// countryCode returns the country code from the GeoJSON feature properties
+func countryCode(properties map[string]interface{}) string {
+	for _, property := range countryCodeProperties {
+		if code, ok := properties[property].(string); ok && len(code) == 2 {
+			return strings.ToUpper(code)
+		}
+	}
+
+	return ""
+}
+
+// geoJSONRings returns the rings of the polygon or multipolygon geometry, the holes are
+// returned as the rings too
+func geoJSONRings(geometry geoJSONGeometry) ([][]s2.LatLng, error) {
+	data, err := json.Marshal(geometry.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+
+	var polygons [][][][]float64
+	switch geometry.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(data, &polygon); err != nil {
+			return nil, err
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(data, &polygons); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	var rings [][]s2.LatLng
+	for _, polygon := range polygons {
+		for _, ring := range polygon {
+			var points []s2.LatLng
+			for _, position := range ring {
+				if len(position) < 2 {
+					return nil, fmt.Errorf("wrong position %v", position)
+				}
+
+				lat := math.Max(-maxMercatorLat, math.Min(maxMercatorLat, position[1]))
+				points = append(points, s2.LatLngFromDegrees(lat, position[0]))
+			}
+
+			if len(points) > 2 {
+				rings = append(rings, points)
+			}
+		}
+	}
+
+	return rings, nil
+}
+
+// loadCountryShapes loads the country boundaries from the GeoJSON file, e.g. Natural Earth
+// admin 0 countries. The features without the country code or polygons are skipped
+func loadCountryShapes(fileName string) ([]countryShape, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %v", fileName, err)
+	}
+
+	var shapes []countryShape
+	for _, feature := range collection.Features {
+		code := countryCode(feature.Properties)
+		if code == "" {
+			continue
+		}
+
+		rings, err := geoJSONRings(feature.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s boundary: %v", code, err)
+		}
+
+		if len(rings) > 0 {
+			shapes = append(shapes, countryShape{code: code, rings: rings})
+		}
+	}
+
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("no countries with %s properties in %s", strings.Join(countryCodeProperties, ", "), fileName)
+	}
+
+	return shapes, nil
+}
+
+// countryColors returns the shading colours of the visited countries, the more time is flown
+// in the country the darker it is
+func countryColors(places []placeTotals) (map[string]color.Color, error) {
+	ramp, err := parseColorRamp(defaultCountryColors)
+	if err != nil {
+		return nil, err
+	}
+
+	maxHours := 0.0
+	for _, p := range places {
+		maxHours = math.Max(maxHours, p.time.Hours())
+	}
+
+	colors := make(map[string]color.Color)
+	for _, p := range places {
+		intensity := 1.0
+		if maxHours > 0 {
+			intensity = math.Log1p(p.time.Hours()) / math.Log1p(maxHours)
+		}
+
+		c := rampColor(ramp, intensity)
+		c.A = uint8(math.Round(0xff * countryAlpha))
+		colors[strings.ToUpper(p.country)] = c
+	}
+
+	return colors, nil
+}
+
+// missingCountries returns the visited countries without the boundaries in the countries file
+func (s mapScene) missingCountries() []string {
+	known := make(map[string]struct{})
+	for _, shape := range s.countryShapes {
+		known[shape.code] = struct{}{}
+	}
+
+	var missing []string
+	for code := range s.countryColors {
+		if _, ok := known[code]; !ok {
+			missing = append(missing, code)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
+
+// countryRings returns the rings of the country in pixels. The points are not wrapped around
+// the antimeridian one by one as the airports, the whole country is shifted to the visible
+// area instead, and repeated in case it's visible more than once
+func (s mapScene) countryRings(shape countryShape) [][][2]float64 {
+	numTiles := math.Exp2(float64(s.zoom))
+	tileSize := float64(s.tileSize)
+	world := numTiles * tileSize
+	cx, cy := mercatorTile(s.center, numTiles)
+
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	var rings [][][2]float64
+	for _, ring := range shape.rings {
+		var points [][2]float64
+		for _, point := range ring {
+			tx, ty := mercatorTile(point, numTiles)
+			x := float64(s.width/2) + (tx-cx)*tileSize
+			y := float64(s.height/2) + (ty-cy)*tileSize
+
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			points = append(points, [2]float64{x, y})
+		}
+		rings = append(rings, points)
+	}
+
+	var result [][][2]float64
+	for k := math.Floor(-maxX / world); k <= math.Ceil((float64(s.width)-minX)/world); k++ {
+		offset := k * world
+		if maxX+offset <= 0 || minX+offset >= float64(s.width) {
+			continue
+		}
+
+		for _, ring := range rings {
+			shifted := make([][2]float64, len(ring))
+			for i, point := range ring {
+				shifted[i] = [2]float64{point[0] + offset, point[1]}
+			}
+			result = append(result, shifted)
+		}
+	}
+
+	return result
+}
+
+// shadedCountries returns the visited countries found in the countries file
+func (s mapScene) shadedCountries() []shadedCountry {
+	var countries []shadedCountry
+	for _, shape := range s.countryShapes {
+		c, ok := s.countryColors[shape.code]
+		if !ok {
+			continue
+		}
+
+		if rings := s.countryRings(shape); len(rings) > 0 {
+			countries = append(countries, shadedCountry{rings: rings, color: c})
+		}
+	}
+
+	return countries
+}
+
+// drawCountries shades the visited countries in the graphical context, the holes of the
+// countries are kept with the even-odd rule
+func drawCountries(dc *gg.Context, scene mapScene) {
+	dc.SetFillRule(gg.FillRuleEvenOdd)
+	for _, country := range scene.shadedCountries() {
+		dc.ClearPath()
+		for _, ring := range country.rings {
+			dc.MoveTo(ring[0][0], ring[0][1])
+			for _, point := range ring[1:] {
+				dc.LineTo(point[0], point[1])
+			}
+			dc.ClosePath()
+		}
+		dc.SetColor(country.color)
+		dc.Fill()
+	}
+	dc.SetFillRule(gg.FillRuleWinding)
+}
+
+// countriesObject draws the visited countries under the routes and markers of the static
+// maps library
+type countriesObject struct {
+	scene mapScene
+}
+
+// Bounds returns the empty rect, the countries don't change the map view
+func (o countriesObject) Bounds() s2.Rect {
+	return s2.EmptyRect()
+}
+
+// ExtraMarginPixels returns no margins
+func (o countriesObject) ExtraMarginPixels() (float64, float64, float64, float64) {
+	return 0, 0, 0, 0
+}
+
+// Draw draws the countries on the image of the static maps library, which is bigger than the
+// map and cropped around the map center later
+func (o countriesObject) Draw(dc *gg.Context, trans *sm.Transformer) {
+	x, y := trans.LatLngToXY(o.scene.center)
+
+	dc.Push()
+	dc.Translate(x-float64(o.scene.width/2), y-float64(o.scene.height/2))
+	drawCountries(dc, o.scene)
+	dc.Pop()
+}
@@ -0,0 +1,94 @@
+package logbook
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/magiconair/properties/assert"
+)
+
+var testCountriesGeoJSON = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "properties": {"ISO_A2": "CZ"}, "geometry": {"type": "Polygon", "coordinates": [
+		[[12, 48.5], [19, 48.5], [19, 51], [12, 51], [12, 48.5]],
+		[[16, 49], [16.5, 49], [16.5, 49.5], [16, 49]]
+	]}},
+	{"type": "Feature", "properties": {"ISO_A2": "-99", "ISO_A2_EH": "FR"}, "geometry": {"type": "MultiPolygon", "coordinates": [
+		[[[-1, 43], [7, 43], [7, 49], [-1, 49], [-1, 43]]]
+	]}},
+	{"type": "Feature", "properties": {"iso_a2": "XX"}, "geometry": null},
+	{"type": "Feature", "properties": {"name": "Nowhere"}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}
+]}`
+
+func TestCountryShapes(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "countries.geojson")
+	assert.Equal(t, os.WriteFile(fileName, []byte(testCountriesGeoJSON), 0644), nil)
+
+	shapes, err := loadCountryShapes(fileName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(shapes), 2)
+	assert.Equal(t, shapes[0].code, "CZ")
+	assert.Equal(t, len(shapes[0].rings), 2)
+	assert.Equal(t, shapes[1].code, "FR")
+
+	_, err = loadCountryShapes(filepath.Join(t.TempDir(), "missing.geojson"))
+	assert.Equal(t, err != nil, true)
+
+	// the country close to the antimeridian is repeated on the map narrower than its width
+	scene := mapScene{width: 512, height: 256, tileSize: 256, center: s2.LatLngFromDegrees(0, 0)}
+	rings := scene.countryRings(countryShape{code: "FJ", rings: [][]s2.LatLng{{
+		s2.LatLngFromDegrees(-16, 170), s2.LatLngFromDegrees(-16, 179), s2.LatLngFromDegrees(-19, 179),
+	}}})
+	assert.Equal(t, len(rings), 2)
+	assert.Equal(t, rings[0][0][0]+256, rings[1][0][0])
+}
+
+func TestCountriesMap(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "countries.geojson")
+	assert.Equal(t, os.WriteFile(fileName, []byte(testCountriesGeoJSON), 0644), nil)
+
+	cfg := LogbookConfig{MBTilesFile: emptyMBTiles(t), MapWidth: 400, MapHeight: 300, MapCountries: fileName}
+	scene, err := buildMapScene(cfg, testMapRows, testCountryAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, scene.countries, 2)
+	assert.Equal(t, scene.missingCountries(), []string{"ES"})
+
+	// the most flown country has the last colour of the ramp
+	assert.Equal(t, scene.countryColors["CZ"], color.Color(color.NRGBA{0x08, 0x51, 0x9c, 0x80}))
+	assert.Equal(t, len(scene.shadedCountries()), 1)
+
+	img, err := renderMapImage(scene, cfg, true)
+	assert.Equal(t, err, nil)
+
+	x, y := scene.project(s2.LatLngFromDegrees(48.8, 18))
+	_, _, _, a := img.At(int(x), int(y)).RGBA()
+	assert.Equal(t, a > 0, true)
+
+	// the hole and France are not shaded
+	x, y = scene.project(s2.LatLngFromDegrees(49.1, 16.3))
+	_, _, _, a = img.At(int(x), int(y)).RGBA()
+	assert.Equal(t, a, uint32(0))
+	x, y = scene.project(s2.LatLngFromDegrees(46, 1))
+	_, _, _, a = img.At(int(x), int(y)).RGBA()
+	assert.Equal(t, a, uint32(0))
+
+	var buf bytes.Buffer
+	err = writeMapSVG(&buf, scene, img)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.Count(buf.String(), "<path "), 1)
+	assert.Equal(t, strings.Count(buf.String(), " Z M"), 1)
+
+	assert.Equal(t, saveMap(filepath.Join(t.TempDir(), "map.pdf"), scene, cfg), nil)
+
+	cfg.MapLegend = true
+	scene, err = buildMapScene(cfg, testMapRows, testCountryAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, scene.legend[len(scene.legend)-1].text, "Countries: 2")
+
+	_, err = buildMapScene(LogbookConfig{MapCountries: filepath.Join(t.TempDir(), "missing.geojson")}, testMapRows, testCountryAirports)
+	assert.Equal(t, err != nil, true)
+}
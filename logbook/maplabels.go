@@ -0,0 +1,228 @@
+package logbook
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// font size of the airport labels
+var mapLabelFontSize = 14.0
+
+// marker size range in pixels for the scaling by the number of visits
+var minMarkerSize = 10.0
+var maxMarkerSize = 28.0
+
+// colours of the airport markers
+var markerColor = color.RGBA{0xff, 0, 0, 0xff}
+var homeBaseColor = color.RGBA{0x1f, 0x77, 0xb4, 0xff}
+
+// mapRect is the rectangle on the map in pixels
+type mapRect struct {
+	x, y          float64
+	width, height float64
+}
+
+// overlaps returns if the rectangles have a common area
+func (r mapRect) overlaps(o mapRect) bool {
+	return r.x < o.x+o.width && o.x < r.x+r.width && r.y < o.y+o.height && o.y < r.y+r.height
+}
+
+// inside returns if the rectangle is inside the map
+func (r mapRect) inside(width int, height int) bool {
+	return r.x >= 0 && r.y >= 0 && r.x+r.width <= float64(width) && r.y+r.height <= float64(height)
+}
+
+// mapLabel is the airport code with its box on the map
+type mapLabel struct {
+	text     string
+	box      mapRect
+	textX    float64
+	textY    float64 // text baseline
+	fontSize float64
+}
+
+// markerLabel returns the label of the airport marker
+//
+// airport Airport - airport from the database
+//
+// labels string - "icao" or "iata", the ICAO code is used for the airports without IATA code
+func markerLabel(airport Airport, labels string) (string, error) {
+	switch labels {
+	case "":
+		return "", nil
+	case "icao":
+		return airport.ICAO, nil
+	case "iata":
+		if airport.IATA != "" {
+			return airport.IATA, nil
+		}
+		return airport.ICAO, nil
+	}
+
+	return "", fmt.Errorf("unknown airport labels %s, should be icao or iata", labels)
+}
+
+// markerSize returns the size of the airport marker, in case of the scaling the marker area
+// is proportional to the number of visits
+func markerSize(visits int, maxVisits int, scaled bool) float64 {
+	if !scaled || maxVisits <= 1 {
+		return mapMarkerSize
+	}
+
+	return minMarkerSize + (maxMarkerSize-minMarkerSize)*math.Sqrt(float64(visits)/float64(maxVisits))
+}
+
+// markerBox returns the area of the marker pin
+func markerBox(x, y, size float64) mapRect {
+	return mapRect{x: x - size/2, y: y - 1.5*size, width: size, height: 1.5 * size}
+}
+
+// placeLabels places the labels of the markers next to them. The markers with higher priority
+// get their labels first, the label is skipped in case it overlaps other labels, markers or
+// the legend in all positions
+func (s *mapScene) placeLabels() error {
+	s.labels = nil
+	s.hiddenLabels = 0
+
+	fontSize := mapLabelFontSize * s.scale()
+	face, err := mapFontFace(fontSize)
+	if err != nil {
+		return err
+	}
+
+	padding := fontSize / 5
+	gap := fontSize / 4
+	metrics := face.Metrics()
+	ascent := float64(metrics.Ascent) / 64
+	height := ascent + float64(metrics.Descent)/64 + 2*padding
+
+	var obstacles []mapRect
+	for _, marker := range s.markers {
+		x, y := s.project(marker.position)
+		obstacles = append(obstacles, markerBox(x, y, marker.size))
+	}
+
+	if len(s.legend) > 0 {
+		l, err := newLegendLayout(s.legend, s.scale(), s.height)
+		if err != nil {
+			return err
+		}
+		obstacles = append(obstacles, mapRect{x: l.x, y: l.y, width: l.width, height: l.height})
+	}
+
+	markers := make([]mapMarker, len(s.markers))
+	copy(markers, s.markers)
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].priority > markers[j].priority })
+
+	for _, marker := range markers {
+		if marker.label == "" {
+			continue
+		}
+
+		x, y := s.project(marker.position)
+		width := float64(font.MeasureString(face, marker.label))/64 + 2*padding
+		center := y - marker.size
+
+		candidates := []mapRect{
+			{x: x + marker.size/2 + gap, y: center - height/2},         // right
+			{x: x - marker.size/2 - gap - width, y: center - height/2}, // left
+			{x: x - width/2, y: y - 1.5*marker.size - gap - height},    // above
+			{x: x - width/2, y: y + gap},                               // below
+		}
+
+		placed := false
+		for _, box := range candidates {
+			box.width, box.height = width, height
+			if !box.inside(s.width, s.height) {
+				continue
+			}
+
+			free := true
+			for _, obstacle := range obstacles {
+				if box.overlaps(obstacle) {
+					free = false
+					break
+				}
+			}
+			if !free {
+				continue
+			}
+
+			s.labels = append(s.labels, mapLabel{
+				text:     marker.label,
+				box:      box,
+				textX:    box.x + padding,
+				textY:    box.y + padding + ascent,
+				fontSize: fontSize,
+			})
+			obstacles = append(obstacles, box)
+			placed = true
+			break
+		}
+
+		if !placed {
+			s.hiddenLabels++
+		}
+	}
+
+	return nil
+}
+
+// drawLabels draws the airport labels in the graphical context
+func drawLabels(dc *gg.Context, labels []mapLabel) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	face, err := mapFontFace(labels[0].fontSize)
+	if err != nil {
+		return err
+	}
+	dc.SetFontFace(face)
+
+	for _, label := range labels {
+		dc.SetColor(color.RGBA{0xff, 0xff, 0xff, 0xd0})
+		dc.DrawRectangle(label.box.x, label.box.y, label.box.width, label.box.height)
+		dc.Fill()
+
+		dc.SetColor(color.Black)
+		dc.DrawString(label.text, label.textX, label.textY)
+	}
+
+	return nil
+}
+
+// drawMapLabels draws the airport labels on the rendered map
+func drawMapLabels(img image.Image, labels []mapLabel) (image.Image, error) {
+	if len(labels) == 0 {
+		return img, nil
+	}
+
+	dc := gg.NewContextForImage(img)
+	if err := drawLabels(dc, labels); err != nil {
+		return nil, err
+	}
+
+	return dc.Image(), nil
+}
+
+// isHomeBase returns if the airport is the home base
+func isHomeBase(airport Airport, homeBase string, airports airportsDB) bool {
+	if homeBase == "" {
+		return false
+	}
+
+	base, ok := airports.lookup(homeBase)
+	if !ok {
+		return strings.EqualFold(airport.ICAO, homeBase)
+	}
+
+	return base.ICAO == airport.ICAO
+}
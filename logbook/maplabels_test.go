@@ -0,0 +1,73 @@
+package logbook
+
+import (
+	"math"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestMarkerLabel(t *testing.T) {
+	airport := Airport{ICAO: "LKPR", IATA: "PRG"}
+
+	label, err := markerLabel(airport, "icao")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, label, "LKPR")
+
+	label, err = markerLabel(airport, "iata")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, label, "PRG")
+
+	label, err = markerLabel(Airport{ICAO: "LKTB"}, "iata")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, label, "LKTB")
+
+	_, err = markerLabel(airport, "name")
+	assert.Equal(t, err != nil, true)
+}
+
+func TestMarkerSize(t *testing.T) {
+	assert.Equal(t, markerSize(1, 10, false), mapMarkerSize)
+	assert.Equal(t, markerSize(10, 10, true), maxMarkerSize)
+	assert.Equal(t, markerSize(1, 1, true), mapMarkerSize)
+	assert.Equal(t, markerSize(1, 100, true) < markerSize(25, 100, true), true)
+
+	assert.Equal(t, mapRect{0, 0, 10, 10}.overlaps(mapRect{5, 5, 10, 10}), true)
+	assert.Equal(t, mapRect{0, 0, 10, 10}.overlaps(mapRect{10, 0, 10, 10}), false)
+	assert.Equal(t, mapRect{-1, 0, 10, 10}.inside(100, 100), false)
+}
+
+func TestMapLabels(t *testing.T) {
+	cfg := LogbookConfig{MapWidth: 400, MapHeight: 300, MapLabels: "iata", MapMarkerScale: true, HomeBase: "PRG", MapLegend: true}
+	scene, err := buildMapScene(cfg, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(scene.labels)+scene.hiddenLabels, 3)
+
+	// the home base is drawn on top with its own colour and gets the label first
+	home := scene.markers[len(scene.markers)-1]
+	assert.Equal(t, home.label, "PRG")
+	assert.Equal(t, home.color, homeBaseColor)
+	assert.Equal(t, home.priority, math.MaxInt32)
+	assert.Equal(t, scene.labels[0].text, "PRG")
+
+	// the labels don't overlap each other, the markers and the legend
+	legend, err := newLegendLayout(scene.legend, scene.scale(), scene.height)
+	assert.Equal(t, err, nil)
+	for i, label := range scene.labels {
+		assert.Equal(t, label.box.inside(scene.width, scene.height), true)
+		assert.Equal(t, label.box.overlaps(mapRect{legend.x, legend.y, legend.width, legend.height}), false)
+
+		for _, marker := range scene.markers {
+			x, y := scene.project(marker.position)
+			assert.Equal(t, label.box.overlaps(markerBox(x, y, marker.size)), false)
+		}
+		for _, other := range scene.labels[i+1:] {
+			assert.Equal(t, label.box.overlaps(other.box), false)
+		}
+	}
+
+	// the padding zooms the map out
+	padded, err := buildMapScene(LogbookConfig{MapWidth: 400, MapHeight: 300, MapPadding: 100}, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, padded.zoom < scene.zoom, true)
+}
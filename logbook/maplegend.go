@@ -0,0 +1,138 @@
+package logbook
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+)
+
+// font size of the map legend
+var mapLegendFontSize = 18.0
+
+// legendLine is the line of the map legend, the line with colour has a colour sample
+type legendLine struct {
+	text  string
+	color color.Color
+}
+
+// mapFontFace returns the embedded font face for the map text
+func mapFontFace(size float64) (font.Face, error) {
+	fontBytes, err := content.ReadFile("font/LiberationSansNarrow-Regular.ttf")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := truetype.Parse(fontBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return truetype.NewFace(f, &truetype.Options{Size: size}), nil
+}
+
+// legendLayout is the position and the size of the legend box on the map, it's shared by
+// the raster and the vector map output
+type legendLayout struct {
+	lines       []legendLine
+	face        font.Face
+	fontSize    float64
+	padding     float64
+	lineHeight  float64
+	sampleWidth float64
+	textOffset  float64 // distance from the top of the line to the text baseline
+	x, y        float64
+	width       float64
+	height      float64
+}
+
+// newLegendLayout measures the legend text and places the box in the bottom left corner of the map
+//
+// lines []legendLine - legend text
+//
+// scale float64 - map resolution scale, 1 for 96 dpi
+//
+// mapHeight int - map height in pixels
+func newLegendLayout(lines []legendLine, scale float64, mapHeight int) (legendLayout, error) {
+	l := legendLayout{lines: lines, fontSize: mapLegendFontSize * scale}
+
+	var err error
+	l.face, err = mapFontFace(l.fontSize)
+	if err != nil {
+		return legendLayout{}, err
+	}
+
+	l.padding = l.fontSize / 2
+	l.lineHeight = l.fontSize * 1.3
+	l.sampleWidth = l.fontSize * 2
+	l.textOffset = float64(l.face.Metrics().Height) / 64
+
+	textWidth := 0.0
+	for _, line := range lines {
+		w := float64(font.MeasureString(l.face, line.text)) / 64
+		if line.color != nil {
+			w += l.sampleWidth + l.padding
+		}
+		if w > textWidth {
+			textWidth = w
+		}
+	}
+
+	l.width = textWidth + 2*l.padding
+	l.height = l.lineHeight*float64(len(lines)) + 2*l.padding
+	l.x = l.padding
+	l.y = float64(mapHeight) - l.height - l.padding
+
+	return l, nil
+}
+
+// lineY returns the top of the legend line
+func (l legendLayout) lineY(i int) float64 {
+	return l.y + l.padding + l.lineHeight*float64(i)
+}
+
+// draw draws the legend box with the text lines
+func (l legendLayout) draw(dc *gg.Context) {
+	dc.SetFontFace(l.face)
+
+	dc.SetColor(color.RGBA{0xff, 0xff, 0xff, 0xd0})
+	dc.DrawRectangle(l.x, l.y, l.width, l.height)
+	dc.Fill()
+
+	for i, line := range l.lines {
+		textX := l.x + l.padding
+		lineY := l.lineY(i)
+
+		if line.color != nil {
+			dc.SetColor(line.color)
+			dc.SetLineWidth(l.fontSize / 4)
+			dc.DrawLine(textX, lineY+l.lineHeight/2, textX+l.sampleWidth, lineY+l.lineHeight/2)
+			dc.Stroke()
+			textX += l.sampleWidth + l.padding
+		}
+
+		dc.SetColor(color.Black)
+		dc.DrawString(line.text, textX, lineY+l.textOffset)
+	}
+}
+
+// drawMapLegend draws the box with the text lines in the bottom left corner of the map
+//
+// img image.Image - rendered map
+//
+// lines []legendLine - legend text
+//
+// scale float64 - map resolution scale, 1 for 96 dpi
+func drawMapLegend(img image.Image, lines []legendLine, scale float64) (image.Image, error) {
+	dc := gg.NewContextForImage(img)
+
+	l, err := newLegendLayout(lines, scale, dc.Height())
+	if err != nil {
+		return nil, err
+	}
+	l.draw(dc)
+
+	return dc.Image(), nil
+}
@@ -0,0 +1,197 @@
+package logbook
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+)
+
+// route line width range in pixels for the frequency weighting
+var minRouteWidth = 0.5
+var maxRouteWidth = 4.0
+
+// route line opacity range for the frequency weighting
+var minRouteAlpha = 0.3
+
+// colours of the route groups
+var routePalette = []color.RGBA{
+	{0x1f, 0x77, 0xb4, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff},
+	{0x2c, 0xa0, 0x2c, 0xff},
+	{0xd6, 0x27, 0x28, 0xff},
+	{0x94, 0x67, 0xbd, 0xff},
+	{0x8c, 0x56, 0x4b, 0xff},
+	{0xe3, 0x77, 0xc2, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff},
+	{0xbc, 0xbd, 0x22, 0xff},
+	{0x17, 0xbe, 0xcf, 0xff},
+}
+
+// mapRoute is the route line on the map
+type mapRoute struct {
+	departure string
+	arrival   string
+	group     string
+}
+
+// routeGroup returns the group of the flight used for the route colour
+//
+// record logbookRecord - logbook record
+//
+// colorBy string - "type" for the aircraft model, "year" or "night" for day and night flights
+func routeGroup(record logbookRecord, colorBy string) (string, error) {
+	switch colorBy {
+	case "":
+		return "", nil
+
+	case "type":
+		if record.aircraft.model == "" {
+			return "Unknown", nil
+		}
+		return record.aircraft.model, nil
+
+	case "year":
+		date, err := time.Parse(dateLayout, record.date)
+		if err != nil {
+			return "Unknown", nil
+		}
+		return fmt.Sprintf("%d", date.Year()), nil
+
+	case "night":
+		// the flight is a night one in case most of it was at night
+		if record.time.night.time > 0 && record.time.night.time*2 >= record.time.total.time {
+			return "Night", nil
+		}
+		return "Day", nil
+	}
+
+	return "", fmt.Errorf("unknown route colour mode %s, should be type, year or night", colorBy)
+}
+
+// collectMapRoutes returns the number of flights for every route and group, the flights in
+// both directions are the same route
+func collectMapRoutes(records []logbookRecord, colorBy string) (map[mapRoute]int, error) {
+	routes := make(map[mapRoute]int)
+
+	for _, record := range records {
+		if record.departure.place == "" || record.departure.place == record.arrival.place {
+			continue
+		}
+
+		group, err := routeGroup(record, colorBy)
+		if err != nil {
+			return nil, err
+		}
+
+		departure, arrival := record.departure.place, record.arrival.place
+		if arrival < departure {
+			departure, arrival = arrival, departure
+		}
+
+		routes[mapRoute{departure: departure, arrival: arrival, group: group}]++
+	}
+
+	return routes, nil
+}
+
+// airportGroups returns the group of the most flights from and to every airport used for the
+// marker colour, the airports are the ICAO codes from the airports database. In case of the
+// same number of flights the first group in the sorted order is used
+//
+// records []logbookRecord - logbook records
+//
+// colorBy string - "type" for the aircraft model, "year" or "night" for day and night flights
+//
+// airports airportsDB - airports database
+func airportGroups(records []logbookRecord, colorBy string, airports airportsDB) (map[string]string, error) {
+	flights := make(map[string]map[string]int)
+
+	for _, record := range records {
+		if record.departure.place == "" && record.arrival.place == "" {
+			continue
+		}
+
+		group, err := routeGroup(record, colorBy)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, code := range []string{record.departure.place, record.arrival.place} {
+			airport, ok := airports.lookup(code)
+			if code == "" || !ok {
+				continue
+			}
+
+			if flights[airport.ICAO] == nil {
+				flights[airport.ICAO] = make(map[string]int)
+			}
+			flights[airport.ICAO][group]++
+		}
+	}
+
+	groups := make(map[string]string)
+	for icao, groupFlights := range flights {
+		dominant, maxFlights := "", 0
+		for group, n := range groupFlights {
+			if n > maxFlights || (n == maxFlights && group < dominant) {
+				dominant, maxFlights = group, n
+			}
+		}
+		groups[icao] = dominant
+	}
+
+	return groups, nil
+}
+
+// groupColors assigns the palette colours to the route and marker groups in the sorted order
+func groupColors(routes map[mapRoute]int, markers map[string]string) map[string]color.Color {
+	groupsSet := make(map[string]struct{})
+	for route := range routes {
+		groupsSet[route.group] = struct{}{}
+	}
+	for _, group := range markers {
+		groupsSet[group] = struct{}{}
+	}
+
+	var groups []string
+	for group := range groupsSet {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	colors := make(map[string]color.Color)
+	for i, group := range groups {
+		if group == "" {
+			colors[group] = color.Black
+		} else {
+			colors[group] = routePalette[i%len(routePalette)]
+		}
+	}
+
+	return colors
+}
+
+// routeStyle returns the colour and the width of the route line. In case of the frequency
+// weighting the most flown routes are wider and more opaque
+//
+// base color.Color - colour of the route group
+//
+// flights int - number of flights on the route
+//
+// maxFlights int - maximum number of flights on one route
+//
+// weighted bool - use the frequency weighting
+func routeStyle(base color.Color, flights int, maxFlights int, weighted bool) (color.Color, float64) {
+	if !weighted || maxFlights <= 1 {
+		return base, minRouteWidth
+	}
+
+	ratio := float64(flights-1) / float64(maxFlights-1)
+	alpha := minRouteAlpha + (1-minRouteAlpha)*ratio
+
+	r, g, b, _ := base.RGBA()
+	c := color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(alpha * 0xff)}
+
+	return c, minRouteWidth + (maxRouteWidth-minRouteWidth)*ratio
+}
@@ -0,0 +1,72 @@
+package logbook
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestMapRoutes(t *testing.T) {
+	var records []logbookRecord
+	for _, row := range [][]interface{}{
+		{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35", "", "1", "03:35"},
+		{"09/10/2021", "LKPR", "0600", "LEMG", "0930", "B738", "OK-TVS", "", "03:30", "03:30", "03:30", "1"},
+		{"10/10/2020", "LKPR", "0600", "LEMG", "0930", "A320", "OK-TVA", "", "03:30", "03:30", "03:30", "1"},
+		{"11/10/2021", "LKPR", "0800", "LKPR", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00", "1"},
+		{"06/03/2020", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "01:30"},
+	} {
+		records = append(records, parseRecord(row))
+	}
+
+	routes, err := collectMapRoutes(records, "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(routes), 1)
+	assert.Equal(t, routes[mapRoute{departure: "LEMG", arrival: "LKPR"}], 3)
+
+	routes, err = collectMapRoutes(records, "type")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, routes[mapRoute{departure: "LEMG", arrival: "LKPR", group: "B738"}], 2)
+	assert.Equal(t, routes[mapRoute{departure: "LEMG", arrival: "LKPR", group: "A320"}], 1)
+
+	colors := groupColors(routes, nil)
+	assert.Equal(t, colors["A320"], color.Color(routePalette[0]))
+	assert.Equal(t, colors["B738"], color.Color(routePalette[1]))
+
+	routes, err = collectMapRoutes(records, "year")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, routes[mapRoute{departure: "LEMG", arrival: "LKPR", group: "2021"}], 2)
+
+	routes, err = collectMapRoutes(records, "night")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, routes[mapRoute{departure: "LEMG", arrival: "LKPR", group: "Night"}], 1)
+	assert.Equal(t, routes[mapRoute{departure: "LEMG", arrival: "LKPR", group: "Day"}], 2)
+
+	_, err = collectMapRoutes(records, "colour")
+	assert.Equal(t, err != nil, true)
+
+	// the markers have the group of the most flights, A320 wins the tie at LEMG by the name
+	groups, err := airportGroups(records[1:], "type", testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, groups, map[string]string{"LEMG": "A320", "LKPR": "C152"})
+
+	colors = groupColors(routes, map[string]string{"LKPD": "Unknown"})
+	assert.Equal(t, len(colors), 3)
+	assert.Equal(t, colors["Unknown"], color.Color(routePalette[2]))
+}
+
+func TestRouteStyle(t *testing.T) {
+	c, width := routeStyle(color.Black, 1, 10, false)
+	assert.Equal(t, c, color.Color(color.Black))
+	assert.Equal(t, width, minRouteWidth)
+
+	c, width = routeStyle(routePalette[0], 10, 10, true)
+	assert.Equal(t, width, maxRouteWidth)
+	_, _, _, a := c.RGBA()
+	assert.Equal(t, a, uint32(0xffff))
+
+	c, width = routeStyle(routePalette[0], 1, 10, true)
+	assert.Equal(t, width, minRouteWidth)
+	_, _, _, a = c.RGBA()
+	assert.Equal(t, a < 0x8000, true)
+}
@@ -0,0 +1,520 @@
+package logbook
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sm "github.com/flopp/go-staticmaps"
+	"github.com/fogleman/gg"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// default map size in pixels and resolution
+var defaultMapWidth = 1920
+var defaultMapHeight = 1080
+var defaultMapDPI = 96.0
+
+// size of the airport marker in pixels at the default resolution
+var mapMarkerSize = 16.0
+
+// zoom level for the map with the single airport
+var singlePointZoom = 15
+
+// mapLine is the route segment on the map
+type mapLine struct {
+	points []s2.LatLng
+	color  color.Color
+	width  float64
+}
+
+// mapMarker is the airport marker on the map, the markers with higher priority get their
+// labels first
+type mapMarker struct {
+	position s2.LatLng
+	color    color.Color
+	size     float64
+	label    string
+	priority int
+}
+
+// mapScene keeps the map objects independent of the output format. The zoom and the center
+// are set explicitly, so the raster basemap and the vector objects share the same projection
+type mapScene struct {
+	width    int
+	height   int
+	dpi      float64
+	tileSize int
+	padding  float64
+	zoom     int
+	center   s2.LatLng
+
+	countryShapes []countryShape
+	countryColors map[string]color.Color
+	lines         []mapLine
+	markers       []mapMarker
+	labels        []mapLabel
+	heat          []heatPoint
+	heatRadius    float64
+	heatRamp      []color.NRGBA
+	hiddenLabels  int
+	legend        []legendLine
+	colors        map[string]color.Color
+
+	airports        int
+	routes          int
+	countries       int
+	missingAirports []string
+	totals          logbookTotalRecord
+}
+
+// scale returns the scale of the line widths, marker sizes and fonts for the map resolution
+func (s mapScene) scale() float64 {
+	return s.dpi / defaultMapDPI
+}
+
+// project returns the pixel coordinates of the point on the map, the same way as the static
+// maps library does for the cropped image
+func (s mapScene) project(ll s2.LatLng) (float64, float64) {
+	numTiles := math.Exp2(float64(s.zoom))
+	tileSize := float64(s.tileSize)
+
+	tx, ty := mercatorTile(ll, numTiles)
+	cx, cy := mercatorTile(s.center, numTiles)
+
+	x := float64(s.width/2) + (tx-cx)*tileSize
+	y := float64(s.height/2) + (ty-cy)*tileSize
+
+	// wrap the point around the antimeridian into the visible area
+	world := numTiles * tileSize
+	for x < 0 {
+		x += world
+	}
+	for x >= float64(s.width) {
+		x -= world
+	}
+
+	return x, y
+}
+
+// mercatorTile returns the fractional tile index of the point
+func mercatorTile(ll s2.LatLng, numTiles float64) (float64, float64) {
+	x := (ll.Lng.Degrees() + 180.0) / 360.0
+	y := (1.0 - math.Log(math.Tan(ll.Lat.Radians())+1.0/math.Cos(ll.Lat.Radians()))/math.Pi) / 2.0
+
+	return numTiles * x, numTiles * y
+}
+
+// mapTileSize returns the size of the tiles used for the map
+func mapTileSize(logbookConfig LogbookConfig) int {
+	if logbookConfig.MBTilesFile == "" && logbookConfig.TileSize != 0 {
+		return logbookConfig.TileSize
+	}
+
+	return defaultTileSize
+}
+
+// fitMapView sets the zoom and the center of the map to show all routes and markers
+func (s *mapScene) fitMapView() error {
+	bounds := s2.EmptyRect()
+	for _, line := range s.lines {
+		for _, point := range line.points {
+			bounds = bounds.AddPoint(point)
+		}
+	}
+
+	margin := 0.0
+	for _, marker := range s.markers {
+		bounds = bounds.AddPoint(marker.position)
+		// the marker pin is drawn above its position
+		margin = math.Max(margin, 1.5*marker.size+1)
+	}
+
+	for _, point := range s.heat {
+		bounds = bounds.AddPoint(point.position)
+		margin = math.Max(margin, s.heatRadius)
+	}
+
+	if bounds.IsEmpty() {
+		return fmt.Errorf("no airports to show on the map")
+	}
+	margin += s.padding
+
+	// visually centered in mercator projection
+	latLo := bounds.Lo().Lat.Radians()
+	latHi := bounds.Hi().Lat.Radians()
+	yLo := math.Log((1+math.Sin(latLo))/(1-math.Sin(latLo))) / 2
+	yHi := math.Log((1+math.Sin(latHi))/(1-math.Sin(latHi))) / 2
+	s.center = s2.LatLng{Lat: s1.Angle(math.Atan(math.Sinh((yLo + yHi) / 2))), Lng: bounds.Center().Lng}
+
+	if bounds.IsPoint() {
+		s.zoom = singlePointZoom
+		return nil
+	}
+
+	w := (float64(s.width) - 2*margin) / float64(s.tileSize)
+	h := (float64(s.height) - 2*margin) / float64(s.tileSize)
+	if w <= 0 || h <= 0 {
+		w = float64(s.width) / float64(s.tileSize)
+		h = float64(s.height) / float64(s.tileSize)
+	}
+
+	minX, minY := mercatorTile(bounds.Lo(), 1)
+	maxX, maxY := mercatorTile(bounds.Hi(), 1)
+
+	dx := maxX - minX
+	for dx < 0 {
+		dx += 1
+	}
+	dy := math.Abs(maxY - minY)
+
+	s.zoom = 0
+	for zoom := 1; zoom < 20; zoom++ {
+		tiles := math.Exp2(float64(zoom))
+		if dx*tiles > w || dy*tiles > h {
+			break
+		}
+		s.zoom = zoom
+	}
+
+	return nil
+}
+
+// buildMapScene collects the airports markers, the routes and the legend for the map and
+// fits the map view to them
+//
+// logbookConfig LogbookConfig - logbook config with the map settings
+//
+// rows [][]interface{} - logbook rows to show on the map
+//
+// airports airportsDB - airports database
+func buildMapScene(logbookConfig LogbookConfig, rows [][]interface{}, airports airportsDB) (mapScene, error) {
+	scene, err := collectMapScene(logbookConfig, rows, airports, nil)
+	if err != nil {
+		return scene, err
+	}
+
+	if logbookConfig.MapCountries != "" {
+		scene.countryShapes, err = loadCountryShapes(logbookConfig.MapCountries)
+		if err != nil {
+			return scene, fmt.Errorf("cannot load countries: %v", err)
+		}
+	}
+
+	if err := scene.fitMapView(); err != nil {
+		return scene, err
+	}
+
+	return scene, scene.placeLabels()
+}
+
+// collectMapScene collects the airports markers, the routes and the legend for the map
+// without setting the map view. The route group colours are assigned in case colors is nil
+func collectMapScene(logbookConfig LogbookConfig, rows [][]interface{}, airports airportsDB, colors map[string]color.Color) (mapScene, error) {
+	scene := mapScene{
+		width:    logbookConfig.MapWidth,
+		height:   logbookConfig.MapHeight,
+		dpi:      logbookConfig.MapDPI,
+		tileSize: mapTileSize(logbookConfig),
+	}
+	if scene.width <= 0 {
+		scene.width = defaultMapWidth
+	}
+	if scene.height <= 0 {
+		scene.height = defaultMapHeight
+	}
+	if scene.dpi <= 0 {
+		scene.dpi = defaultMapDPI
+	}
+	scene.padding = float64(logbookConfig.MapPadding) * scene.scale()
+
+	var records []logbookRecord
+	for _, row := range rows {
+		record := parseRecord(row)
+		records = append(records, record)
+
+		scene.totals = calculateTotals(scene.totals, record)
+	}
+
+	// the route lines with the number of flights
+	routeLines := make(map[mapRoute]int)
+	if !logbookConfig.FilterNoRoutes {
+		var err error
+		routeLines, err = collectMapRoutes(records, logbookConfig.MapColorBy)
+		if err != nil {
+			return scene, err
+		}
+	}
+
+	uniqueRoutes := make(map[string]struct{})
+	maxFlights := 0
+	for route, flights := range routeLines {
+		uniqueRoutes[routeKey(route.departure, route.arrival)] = struct{}{}
+		if flights > maxFlights {
+			maxFlights = flights
+		}
+	}
+
+	visits, missingAirports := collectAirportVisits(rows, airports)
+	scene.airports = len(visits) + len(missingAirports)
+	scene.missingAirports = missingAirports
+	scene.routes = len(uniqueRoutes)
+
+	// generate routes lines, the most flown routes are drawn on top
+	var routes []mapRoute
+	for route := range routeLines {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routeLines[routes[i]] != routeLines[routes[j]] {
+			return routeLines[routes[i]] < routeLines[routes[j]]
+		}
+		return fmt.Sprint(routes[i]) < fmt.Sprint(routes[j])
+	})
+
+	// the markers have the colour of the most flights from and to the airport
+	var markerGroups map[string]string
+	if logbookConfig.MapColorBy != "" {
+		var err error
+		markerGroups, err = airportGroups(records, logbookConfig.MapColorBy, airports)
+		if err != nil {
+			return scene, err
+		}
+	}
+
+	if colors == nil {
+		colors = groupColors(routeLines, markerGroups)
+	}
+	scene.colors = colors
+	for _, route := range routes {
+		if airport1, ok := airports.lookup(route.departure); ok {
+			if airport2, ok := airports.lookup(route.arrival); ok {
+
+				lineColor, lineWidth := routeStyle(colors[route.group], routeLines[route], maxFlights, logbookConfig.MapRouteWeight)
+				for _, segment := range greatCircleArc(airport1.latLng(), airport2.latLng()) {
+					scene.lines = append(scene.lines, mapLine{points: segment, color: lineColor, width: lineWidth * scene.scale()})
+				}
+
+			}
+		}
+	}
+
+	// the visited countries are shaded in case the countries boundaries are set
+	if logbookConfig.MapCountries != "" {
+		places := collectPlaceTotals(rows, airports, false)
+		scene.countries = len(places)
+
+		var err error
+		scene.countryColors, err = countryColors(places)
+		if err != nil {
+			return scene, err
+		}
+	}
+
+	// generate airports markers
+	maxVisits := 0
+	for _, v := range visits {
+		if v.visits() > maxVisits {
+			maxVisits = v.visits()
+		}
+	}
+
+	// the heatmap is drawn instead of the markers
+	if logbookConfig.MapHeatmap {
+		ramp, err := parseColorRamp(logbookConfig.HeatmapColors)
+		if err != nil {
+			return scene, err
+		}
+		scene.heatRamp = ramp
+
+		scene.heatRadius = logbookConfig.HeatmapRadius * scene.scale()
+		if logbookConfig.HeatmapRadius <= 0 {
+			scene.heatRadius = defaultHeatmapRadius * scene.scale()
+		}
+
+		for _, v := range visits {
+			scene.heat = append(scene.heat, heatPoint{position: v.airport.latLng(), weight: float64(v.visits())})
+		}
+		visits = nil
+	}
+
+	for _, v := range visits {
+		label, err := markerLabel(v.airport, logbookConfig.MapLabels)
+		if err != nil {
+			return scene, err
+		}
+
+		marker := mapMarker{
+			position: v.airport.latLng(),
+			color:    markerColor,
+			size:     markerSize(v.visits(), maxVisits, logbookConfig.MapMarkerScale) * scene.scale(),
+			label:    label,
+			priority: v.visits(),
+		}
+
+		if group, ok := markerGroups[v.airport.ICAO]; ok && colors[group] != nil {
+			marker.color = colors[group]
+		}
+
+		if isHomeBase(v.airport, logbookConfig.HomeBase, airports) {
+			marker.color = homeBaseColor
+			marker.priority = math.MaxInt32
+		}
+
+		scene.markers = append(scene.markers, marker)
+	}
+
+	// the smaller markers are drawn over the bigger ones and the home base on top
+	sort.SliceStable(scene.markers, func(i, j int) bool {
+		if (scene.markers[i].priority == math.MaxInt32) != (scene.markers[j].priority == math.MaxInt32) {
+			return scene.markers[j].priority == math.MaxInt32
+		}
+		return scene.markers[i].size > scene.markers[j].size
+	})
+
+	if logbookConfig.MapLegend {
+		distances := calculateDistanceStats(rows, airports)
+
+		scene.legend = append(scene.legend,
+			legendLine{text: fmt.Sprintf("Airports: %d", scene.airports)},
+			legendLine{text: fmt.Sprintf("Routes: %d", scene.routes)},
+			legendLine{text: fmt.Sprintf("Total time: %s", scene.totals.time.total.GetTime(true))},
+			legendLine{text: fmt.Sprintf("Landings: %d", scene.totals.landings.day+scene.totals.landings.night)},
+			legendLine{text: fmt.Sprintf("Distance: %s", formatDistance(distances.total))},
+		)
+		if len(distances.legs) > 0 {
+			scene.legend = append(scene.legend, legendLine{text: fmt.Sprintf("Longest leg: %s, %s", distances.longest.legName(), formatDistance(distances.longest.distance))})
+		}
+		if logbookConfig.MapCountries != "" {
+			scene.legend = append(scene.legend, legendLine{text: fmt.Sprintf("Countries: %d", scene.countries)})
+		}
+	}
+
+	if logbookConfig.MapColorBy != "" {
+		var groups []string
+		for group := range colors {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+
+		for _, group := range groups {
+			scene.legend = append(scene.legend, legendLine{text: group, color: colors[group]})
+		}
+	}
+
+	return scene, nil
+}
+
+// renderMapImage renders the map with the tiles. The routes, markers and legend are
+// drawn only in case withObjects is set, otherwise it's the basemap for the vector output
+func renderMapImage(scene mapScene, logbookConfig LogbookConfig, withObjects bool) (image.Image, error) {
+	ctx := sm.NewContext()
+	ctx.SetSize(scene.width, scene.height)
+	ctx.SetZoom(scene.zoom)
+	ctx.SetCenter(scene.center)
+
+	closeTiles, err := setupMapTiles(ctx, logbookConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set up map tiles: %v", err)
+	}
+	defer closeTiles()
+
+	if withObjects {
+		if len(scene.countryColors) > 0 {
+			ctx.AddObject(countriesObject{scene: scene})
+		}
+		for _, line := range scene.lines {
+			ctx.AddObject(sm.NewPath(line.points, line.color, line.width))
+		}
+		for _, marker := range scene.markers {
+			ctx.AddObject(sm.NewMarker(marker.position, marker.color, marker.size))
+		}
+	}
+
+	img, err := ctx.Render()
+	if err != nil {
+		return nil, err
+	}
+
+	if withObjects && len(scene.heat) > 0 {
+		dc := gg.NewContextForImage(img)
+		dc.DrawImage(drawHeatmap(scene), 0, 0)
+		img = dc.Image()
+	}
+
+	if withObjects {
+		img, err = drawMapLabels(img, scene.labels)
+		if err != nil {
+			return nil, fmt.Errorf("cannot draw airport labels: %v", err)
+		}
+	}
+
+	if withObjects && len(scene.legend) > 0 {
+		img, err = drawMapLegend(img, scene.legend, scene.scale())
+		if err != nil {
+			return nil, fmt.Errorf("cannot draw map legend: %v", err)
+		}
+	}
+
+	return img, nil
+}
+
+// markerOutline returns the outline of the pin marker with the tip in x, y, the same shape
+// as the marker of the raster map
+func markerOutline(x, y, size float64) [][2]float64 {
+	radius := size / 2
+	steps := 24
+
+	var points [][2]float64
+	for i := 0; i <= steps; i++ {
+		angle := (150.0 + 240.0*float64(i)/float64(steps)) * math.Pi / 180.0
+		points = append(points, [2]float64{x + radius*math.Cos(angle), y - size + radius*math.Sin(angle)})
+	}
+
+	return append(points, [2]float64{x, y})
+}
+
+// mapFormat returns the map output format from the file extension
+func mapFormat(fileName string) (string, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+
+	switch format {
+	case "png", "svg", "pdf":
+		return format, nil
+	}
+
+	return "", fmt.Errorf("unknown map format of %s, should be png, svg or pdf", fileName)
+}
+
+// saveMap renders the map scene to the file, the format is chosen by the file extension
+func saveMap(fileName string, scene mapScene, logbookConfig LogbookConfig) error {
+	format, err := mapFormat(fileName)
+	if err != nil {
+		return err
+	}
+
+	if format == "png" {
+		img, err := renderMapImage(scene, logbookConfig, true)
+		if err != nil {
+			return err
+		}
+		return gg.SavePNG(fileName, img)
+	}
+
+	// the vector routes and markers are drawn over the raster basemap
+	basemap, err := renderMapImage(scene, logbookConfig, false)
+	if err != nil {
+		return err
+	}
+
+	if format == "svg" {
+		return saveMapSVG(fileName, scene, basemap)
+	}
+
+	return saveMapPDF(fileName, scene, basemap)
+}
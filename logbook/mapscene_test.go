@@ -0,0 +1,151 @@
+package logbook
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sm "github.com/flopp/go-staticmaps"
+	"github.com/magiconair/properties/assert"
+)
+
+var testMapRows = [][]interface{}{
+	{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35", "", "1", "03:35"},
+	{"09/10/2021", "LKPR", "0600", "LEMG", "0930", "B738", "OK-TVS", "", "03:30", "03:30", "03:30", "1"},
+	{"11/10/2021", "LKPR", "0800", "LKPD", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00", "1"},
+	{"12/10/2021", "LKPD", "0800", "ZZZZ", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00", "1"},
+}
+
+func TestMapScene(t *testing.T) {
+	scene, err := buildMapScene(LogbookConfig{MapWidth: 800, MapHeight: 600, MapDPI: 192, MapLegend: true}, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, scene.airports, 4)
+	assert.Equal(t, scene.routes, 3)
+	assert.Equal(t, scene.missingAirports, []string{"ZZZZ"})
+	assert.Equal(t, len(scene.markers), 3)
+	assert.Equal(t, scene.markers[0].size, 2*mapMarkerSize)
+	assert.Equal(t, len(scene.legend), 6)
+
+	// all airports are inside the map
+	for _, marker := range scene.markers {
+		x, y := scene.project(marker.position)
+		assert.Equal(t, x > 0 && x < 800, true)
+		assert.Equal(t, y > 0 && y < 600, true)
+	}
+
+	// the projection is the same as the static maps library one after the image is cropped
+	ctx := sm.NewContext()
+	ctx.SetSize(scene.width, scene.height)
+	ctx.SetZoom(scene.zoom)
+	ctx.SetCenter(scene.center)
+	trans, err := ctx.Transformer()
+	assert.Equal(t, err, nil)
+
+	centerX, centerY := trans.LatLngToXY(scene.center)
+	for _, marker := range scene.markers {
+		x, y := trans.LatLngToXY(marker.position)
+		sceneX, sceneY := scene.project(marker.position)
+		assert.Equal(t, math.Abs(x-centerX+float64(scene.width/2)-sceneX) < 1e-6, true)
+		assert.Equal(t, math.Abs(y-centerY+float64(scene.height/2)-sceneY) < 1e-6, true)
+	}
+
+	_, err = buildMapScene(LogbookConfig{}, [][]interface{}{testMapRows[3][:1]}, testAirports)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestMapSceneMarkerColors(t *testing.T) {
+	cfg := LogbookConfig{MapWidth: 800, MapHeight: 600, MapColorBy: "type", MapLegend: true}
+	scene, err := buildMapScene(cfg, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+
+	markerColors := make(map[string]color.Color)
+	for _, marker := range scene.markers {
+		for _, code := range []string{"LEMG", "LKPR", "LKPD"} {
+			if airport, _ := testAirports.lookup(code); airport.latLng() == marker.position {
+				markerColors[code] = marker.color
+			}
+		}
+	}
+
+	// LKPR has more B738 flights than C152 ones
+	assert.Equal(t, markerColors, map[string]color.Color{
+		"LEMG": scene.colors["B738"],
+		"LKPR": scene.colors["B738"],
+		"LKPD": scene.colors["C152"],
+	})
+	assert.Equal(t, scene.colors["B738"] != scene.colors["C152"], true)
+
+	// the groups are in the legend with the same colours
+	legend := make(map[string]color.Color)
+	for _, line := range scene.legend {
+		legend[line.text] = line.color
+	}
+	assert.Equal(t, legend["C152"], scene.colors["C152"])
+
+	// the home base colour is kept
+	cfg.HomeBase = "LKPD"
+	scene, err = buildMapScene(cfg, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, scene.markers[len(scene.markers)-1].color, color.Color(homeBaseColor))
+
+	// the markers are red without the groups
+	scene, err = buildMapScene(LogbookConfig{MapWidth: 800, MapHeight: 600}, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+	for _, marker := range scene.markers {
+		assert.Equal(t, marker.color, color.Color(markerColor))
+	}
+}
+
+func TestMapFormat(t *testing.T) {
+	for fileName, format := range map[string]string{"map.png": "png", "poster.SVG": "svg", "map.pdf": "pdf"} {
+		f, err := mapFormat(fileName)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, f, format)
+	}
+
+	_, err := mapFormat("map.jpg")
+	assert.Equal(t, err != nil, true)
+}
+
+func TestSaveMap(t *testing.T) {
+	dir := t.TempDir()
+
+	mbtilesFile := emptyMBTiles(t)
+
+	cfg := LogbookConfig{MBTilesFile: mbtilesFile, MapWidth: 400, MapHeight: 300, MapColorBy: "type"}
+	scene, err := buildMapScene(cfg, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+
+	// the raster marker is drawn where the vector one is, the first marker is under the legend
+	img, err := renderMapImage(scene, cfg, true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.Bounds(), image.Rect(0, 0, 400, 300))
+
+	x, y := scene.project(scene.markers[1].position)
+	assert.Equal(t, img.At(int(x), int(y-scene.markers[1].size)), scene.markers[1].color)
+
+	var buf bytes.Buffer
+	err = writeMapSVG(&buf, scene, img)
+	assert.Equal(t, err, nil)
+	svg := buf.String()
+	assert.Equal(t, strings.HasPrefix(svg, "<svg "), true)
+	assert.Equal(t, strings.Count(svg, "<polyline "), len(scene.lines))
+	assert.Equal(t, strings.Count(svg, "<polygon "), len(scene.markers))
+	assert.Equal(t, strings.Contains(svg, ">B738</text>"), true)
+
+	for _, fileName := range []string{"map.png", "map.svg", "map.pdf"} {
+		fileName = filepath.Join(dir, fileName)
+		assert.Equal(t, saveMap(fileName, scene, cfg), nil)
+
+		info, err := os.Stat(fileName)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, info.Size() > 0, true)
+	}
+
+	assert.Equal(t, saveMap(filepath.Join(dir, "map.gif"), scene, cfg) != nil, true)
+}
@@ -0,0 +1,199 @@
+package logbook
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sm "github.com/flopp/go-staticmaps"
+)
+
+// default size of the map tile in pixels
+var defaultTileSize = 256
+
+// tileURLPattern converts the url template with {s}, {z}, {x} and {y} placeholders
+// to the pattern used by the static maps library
+func tileURLPattern(template string) string {
+	replacer := strings.NewReplacer(
+		"%", "%%",
+		"{s}", "%[1]s",
+		"{z}", "%[2]d",
+		"{x}", "%[3]d",
+		"{y}", "%[4]d",
+	)
+
+	return replacer.Replace(template)
+}
+
+// newTileProvider returns the tile provider from the config, OpenStreetMap is used by default
+func newTileProvider(logbookConfig LogbookConfig) *sm.TileProvider {
+	if logbookConfig.TileURL == "" {
+		return sm.NewTileProviderOpenStreetMaps()
+	}
+
+	// the provider name is used for the cache directory, so every template has its own one
+	h := fnv.New32a()
+	h.Write([]byte(logbookConfig.TileURL))
+
+	provider := &sm.TileProvider{
+		Name:        fmt.Sprintf("custom-%08x", h.Sum32()),
+		Attribution: logbookConfig.TileAttribution,
+		TileSize:    logbookConfig.TileSize,
+		URLPattern:  tileURLPattern(logbookConfig.TileURL),
+		Shards:      logbookConfig.TileShards,
+	}
+
+	if provider.TileSize == 0 {
+		provider.TileSize = defaultTileSize
+	}
+
+	return provider
+}
+
+// tileCacheDir returns the directory for the downloaded map tiles
+func tileCacheDir(logbookConfig LogbookConfig) (string, error) {
+	dir := logbookConfig.CacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(userDir, "logbook")
+	}
+
+	return filepath.Join(dir, "tiles"), nil
+}
+
+// mbtiles serves the map tiles from the local MBTiles file
+type mbtiles struct {
+	db          *sql.DB
+	attribution string
+	listener    net.Listener
+	server      *http.Server
+}
+
+// openMBTiles opens the MBTiles file and reads its metadata
+func openMBTiles(fileName string) (*mbtiles, error) {
+	// sqlite creates an empty database in case the file doesn't exist
+	if _, err := os.Stat(fileName); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tiles").Scan(&count); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s is not MBTiles file: %v", fileName, err)
+	}
+
+	m := &mbtiles{db: db}
+
+	// the metadata table is optional
+	db.QueryRow("SELECT value FROM metadata WHERE name = 'attribution'").Scan(&m.attribution)
+
+	return m, nil
+}
+
+// ServeHTTP returns the tile for the /z/x/y path
+func (m *mbtiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var z, x, y int
+	if _, err := fmt.Sscanf(r.URL.Path, "/%d/%d/%d", &z, &x, &y); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// MBTiles keeps the rows in TMS scheme, where y axis goes from the south
+	row := (1 << uint(z)) - 1 - y
+
+	var data []byte
+	err := m.db.QueryRow("SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?", z, x, row).Scan(&data)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(data)
+}
+
+// serve starts the local server for the map renderer
+func (m *mbtiles) serve() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	m.listener = listener
+	m.server = &http.Server{Handler: m}
+	go m.server.Serve(listener)
+
+	return nil
+}
+
+// tileProvider returns the tile provider for the local server
+func (m *mbtiles) tileProvider() *sm.TileProvider {
+	return &sm.TileProvider{
+		Name:           "mbtiles",
+		Attribution:    m.attribution,
+		TileSize:       defaultTileSize,
+		URLPattern:     fmt.Sprintf("http://%s/%%[2]d/%%[3]d/%%[4]d", m.listener.Addr().String()),
+		IgnoreNotFound: true,
+	}
+}
+
+// Close stops the local server and closes the MBTiles file
+func (m *mbtiles) Close() error {
+	if m.server != nil {
+		m.server.Close()
+	}
+
+	return m.db.Close()
+}
+
+// setupMapTiles sets the tile provider and the cache for the map. In case the MBTiles file is
+// set the tiles are served from it without the cache. The returned function should be called
+// after the map is rendered
+func setupMapTiles(ctx *sm.Context, logbookConfig LogbookConfig) (func(), error) {
+	if logbookConfig.MBTilesFile != "" {
+		m, err := openMBTiles(logbookConfig.MBTilesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.serve(); err != nil {
+			m.Close()
+			return nil, err
+		}
+
+		ctx.SetTileProvider(m.tileProvider())
+		ctx.SetCache(nil)
+
+		return func() { m.Close() }, nil
+	}
+
+	provider := newTileProvider(logbookConfig)
+	if logbookConfig.TileAttribution != "" {
+		provider.Attribution = logbookConfig.TileAttribution
+	}
+	ctx.SetTileProvider(provider)
+
+	dir, err := tileCacheDir(logbookConfig)
+	if err != nil {
+		return nil, err
+	}
+	ctx.SetCache(sm.NewTileCache(dir, 0755))
+
+	return func() {}, nil
+}
@@ -0,0 +1,105 @@
+package logbook
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	sm "github.com/flopp/go-staticmaps"
+	"github.com/golang/geo/s2"
+	"github.com/magiconair/properties/assert"
+)
+
+// pngTile returns the map tile filled with the colour
+func pngTile(c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	for x := 0; x < 256; x++ {
+		for y := 0; y < 256; y++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// emptyMBTiles returns the MBTiles file without tiles, so the map is rendered without the network
+func emptyMBTiles(t *testing.T) string {
+	fileName := filepath.Join(t.TempDir(), "empty.mbtiles")
+
+	db, err := sql.Open("sqlite", fileName)
+	assert.Equal(t, err, nil)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE tiles (zoom_level integer, tile_column integer, tile_row integer, tile_data blob)")
+	assert.Equal(t, err, nil)
+
+	return fileName
+}
+
+func TestTileProvider(t *testing.T) {
+	assert.Equal(t, tileURLPattern("https://{s}.tiles.example.com/{z}/{x}/{y}.png?key=a%20b"),
+		"https://%[1]s.tiles.example.com/%[2]d/%[3]d/%[4]d.png?key=a%%20b")
+
+	provider := newTileProvider(LogbookConfig{TileURL: "https://{s}.tiles.example.com/{z}/{x}/{y}.png", TileShards: []string{"a", "b"}})
+	assert.Equal(t, provider.TileSize, 256)
+	assert.Equal(t, len(provider.Shards), 2)
+	assert.Equal(t, provider.Name != "osm", true)
+
+	provider = newTileProvider(LogbookConfig{})
+	assert.Equal(t, provider.Name, "osm")
+
+	dir, err := tileCacheDir(LogbookConfig{CacheDir: "/tmp/logbook"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, dir, filepath.Join("/tmp/logbook", "tiles"))
+}
+
+func TestMBTiles(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "world.mbtiles")
+
+	db, err := sql.Open("sqlite", fileName)
+	assert.Equal(t, err, nil)
+	_, err = db.Exec(`CREATE TABLE metadata (name text, value text);
+		CREATE TABLE tiles (zoom_level integer, tile_column integer, tile_row integer, tile_data blob);
+		INSERT INTO metadata VALUES ('attribution', 'Local tiles')`)
+	assert.Equal(t, err, nil)
+
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+
+	// the north west tile is red, the rows are in TMS scheme
+	for x := 0; x < 2; x++ {
+		for row := 0; row < 2; row++ {
+			tile := pngTile(blue)
+			if x == 0 && row == 1 {
+				tile = pngTile(red)
+			}
+			_, err = db.Exec("INSERT INTO tiles VALUES (1, ?, ?, ?)", x, row, tile)
+			assert.Equal(t, err, nil)
+		}
+	}
+	db.Close()
+
+	ctx := sm.NewContext()
+	ctx.SetSize(512, 512)
+	ctx.SetZoom(1)
+	ctx.SetCenter(s2.LatLngFromDegrees(0, 0))
+
+	closeTiles, err := setupMapTiles(ctx, LogbookConfig{MBTilesFile: fileName})
+	assert.Equal(t, err, nil)
+	defer closeTiles()
+
+	img, err := ctx.Render()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.At(10, 10), color.Color(red))
+	assert.Equal(t, img.At(500, 10), color.Color(blue))
+	assert.Equal(t, img.At(10, 300), color.Color(blue))
+
+	_, err = setupMapTiles(sm.NewContext(), LogbookConfig{MBTilesFile: filepath.Join(t.TempDir(), "missing.mbtiles")})
+	assert.Equal(t, err != nil, true)
+}
@@ -0,0 +1,274 @@
+package logbook
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// default delay between the timeline frames in milliseconds
+var timelineFrameDelay = 500
+
+// the last frame of the timeline is shown longer
+var timelineLastFrameFactor = 4
+
+// maximum delay between the timeline frames in milliseconds
+var maxTimelineFrameDelay = 60000
+
+// timelineFrame is the frame of the animated map with all flights till the end of the period
+type timelineFrame struct {
+	caption string
+	rows    [][]interface{}
+}
+
+// timelineFrames splits the logbook to the cumulative frames, one frame per month or year
+// from the first flight to the last one. The records without a valid date are skipped
+//
+// rows [][]interface{} - logbook rows
+//
+// period string - "month" or "year"
+func timelineFrames(rows [][]interface{}, period string) ([]timelineFrame, error) {
+	var step func(time.Time) time.Time
+	var start func(time.Time) time.Time
+	var layout string
+
+	switch period {
+	case "month":
+		start = func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC) }
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		layout = "January 2006"
+	case "year":
+		start = func(t time.Time) time.Time { return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC) }
+		step = func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+		layout = "2006"
+	default:
+		return nil, fmt.Errorf("unknown timeline period %s, should be month or year", period)
+	}
+
+	type datedRow struct {
+		date time.Time
+		row  []interface{}
+	}
+
+	var dated []datedRow
+	for _, row := range rows {
+		date, err := time.Parse(dateLayout, parseRecord(row).date)
+		if err != nil {
+			continue
+		}
+		dated = append(dated, datedRow{date: date, row: row})
+	}
+
+	if len(dated) == 0 {
+		return nil, nil
+	}
+
+	// the logbook can be in any order
+	sort.SliceStable(dated, func(i, j int) bool { return dated[i].date.Before(dated[j].date) })
+
+	var frames []timelineFrame
+	i := 0
+	for p := start(dated[0].date); !p.After(dated[len(dated)-1].date); p = step(p) {
+		end := step(p)
+		for i < len(dated) && dated[i].date.Before(end) {
+			i++
+		}
+
+		frame := timelineFrame{caption: p.Format(layout)}
+		for _, d := range dated[:i] {
+			frame.rows = append(frame.rows, d.row)
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+// drawMapObjects draws the countries, routes, markers, labels and legend of the scene on the basemap
+// the same way as the static maps library draws them
+func drawMapObjects(basemap image.Image, scene mapScene) (*gg.Context, error) {
+	dc := gg.NewContext(scene.width, scene.height)
+
+	// the basemap can have transparent parts in case the tiles are missing
+	dc.SetColor(color.White)
+	dc.Clear()
+	dc.DrawImage(basemap, 0, 0)
+	drawCountries(dc, scene)
+
+	dc.SetLineCap(gg.LineCapRound)
+	dc.SetLineJoin(gg.LineJoinRound)
+
+	for _, line := range scene.lines {
+		dc.ClearPath()
+		for _, point := range line.points {
+			dc.LineTo(scene.project(point))
+		}
+		dc.SetColor(line.color)
+		dc.SetLineWidth(line.width)
+		dc.Stroke()
+	}
+
+	if len(scene.heat) > 0 {
+		dc.DrawImage(drawHeatmap(scene), 0, 0)
+	}
+
+	dc.SetLineWidth(1)
+	for _, marker := range scene.markers {
+		x, y := scene.project(marker.position)
+
+		dc.ClearPath()
+		for _, point := range markerOutline(x, y, marker.size) {
+			dc.LineTo(point[0], point[1])
+		}
+		dc.ClosePath()
+		dc.SetColor(marker.color)
+		dc.FillPreserve()
+		dc.SetColor(color.Black)
+		dc.Stroke()
+	}
+
+	if err := drawLabels(dc, scene.labels); err != nil {
+		return nil, err
+	}
+
+	if len(scene.legend) > 0 {
+		l, err := newLegendLayout(scene.legend, scene.scale(), scene.height)
+		if err != nil {
+			return nil, err
+		}
+		l.draw(dc)
+	}
+
+	return dc, nil
+}
+
+// timelineFormat returns the animation format from the file extension
+func timelineFormat(fileName string) (string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".gif":
+		return "gif", nil
+	case ".png":
+		return "apng", nil
+	}
+
+	return "", fmt.Errorf("unknown timeline format of %s, should be gif or png", fileName)
+}
+
+// renderMapTimeline renders the animated map with the routes and airports appearing in the
+// chronological order. All frames have the same view fitted to all flights
+//
+// fileName string - output gif or png (animated png) file
+//
+// logbookConfig LogbookConfig - logbook config with the map settings
+//
+// rows [][]interface{} - logbook rows
+//
+// airports airportsDB - airports database
+func renderMapTimeline(fileName string, logbookConfig LogbookConfig, rows [][]interface{}, airports airportsDB) (int, error) {
+	format, err := timelineFormat(fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	if logbookConfig.MapFrameDelay < 0 || logbookConfig.MapFrameDelay > maxTimelineFrameDelay {
+		return 0, fmt.Errorf("wrong frame delay %d, should be from 1 to %d milliseconds", logbookConfig.MapFrameDelay, maxTimelineFrameDelay)
+	}
+
+	frames, err := timelineFrames(rows, logbookConfig.MapTimeline)
+	if err != nil {
+		return 0, err
+	}
+	if len(frames) == 0 {
+		return 0, fmt.Errorf("no records with dates for the timeline")
+	}
+
+	full, err := buildMapScene(logbookConfig, frames[len(frames)-1].rows, airports)
+	if err != nil {
+		return 0, err
+	}
+
+	basemap, err := renderMapImage(full, logbookConfig, false)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := logbookConfig.MapFrameDelay
+	if delay <= 0 {
+		delay = timelineFrameDelay
+	}
+
+	// the frames are kept compressed or with the palette, the timeline can be long
+	var apng apngEncoder
+	animation := &gif.GIF{}
+
+	for i, frame := range frames {
+		scene, err := collectMapScene(logbookConfig, frame.rows, airports, full.colors)
+		if err != nil {
+			return 0, err
+		}
+
+		scene.zoom, scene.center = full.zoom, full.center
+		scene.countryShapes = full.countryShapes
+		if err := scene.placeLabels(); err != nil {
+			return 0, err
+		}
+
+		dc, err := drawMapObjects(basemap, scene)
+		if err != nil {
+			return 0, err
+		}
+
+		// the date and the total time counter in the top left corner
+		caption, err := newLegendLayout([]legendLine{
+			{text: frame.caption},
+			{text: fmt.Sprintf("Total time: %s", scene.totals.time.total.GetTime(true))},
+		}, scene.scale()*4/3, scene.height)
+		if err != nil {
+			return 0, err
+		}
+		caption.y = caption.padding
+		caption.draw(dc)
+
+		frameDelay := delay
+		if i == len(frames)-1 {
+			frameDelay = delay * timelineLastFrameFactor
+		}
+
+		img := dc.Image()
+		if format == "apng" {
+			if err := apng.addFrame(img, frameDelay); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, image.Point{})
+
+		animation.Image = append(animation.Image, paletted)
+		// gif delays are in 1/100 of second
+		animation.Delay = append(animation.Delay, frameDelay/10)
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if format == "apng" {
+		return len(frames), apng.encode(f)
+	}
+
+	return len(frames), gif.EncodeAll(f, animation)
+}
@@ -0,0 +1,122 @@
+package logbook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestTimelineFrames(t *testing.T) {
+	rows := [][]interface{}{
+		{"12/01/2022", "LKPD", "0800", "LKPR", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00", "1"},
+		{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35", "", "1", "03:35"},
+		{"09/10/2021", "LKPR", "0600", "LEMG", "0930", "B738", "OK-TVS", "", "03:30", "03:30", "03:30", "1"},
+		{"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "01:30"},
+	}
+
+	frames, err := timelineFrames(rows, "month")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(frames), 4)
+	assert.Equal(t, frames[0].caption, "October 2021")
+	assert.Equal(t, len(frames[0].rows), 2)
+	assert.Equal(t, len(frames[2].rows), 2)
+	assert.Equal(t, frames[3].caption, "January 2022")
+	assert.Equal(t, len(frames[3].rows), 3)
+
+	frames, err = timelineFrames(rows, "year")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(frames), 2)
+	assert.Equal(t, frames[1].caption, "2022")
+
+	_, err = timelineFrames(rows, "week")
+	assert.Equal(t, err != nil, true)
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	var frames []image.Image
+	for _, c := range []color.Color{color.White, color.Black, color.White} {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		for x := 0; x < 8; x++ {
+			for y := 0; y < 8; y++ {
+				img.Set(x, y, c)
+			}
+		}
+		frames = append(frames, img)
+	}
+
+	var apng apngEncoder
+	for i, frame := range frames {
+		assert.Equal(t, apng.addFrame(frame, 100*(i+1)), nil)
+	}
+
+	var buf bytes.Buffer
+	assert.Equal(t, apng.encode(&buf), nil)
+
+	chunks, err := readPNGChunks(buf.Bytes())
+	assert.Equal(t, err, nil)
+
+	var names []string
+	for _, chunk := range chunks {
+		names = append(names, chunk.name)
+	}
+	assert.Equal(t, names, []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"})
+
+	// the delays are in 1/100 of second
+	assert.Equal(t, binary.BigEndian.Uint16(chunks[4].data[20:]), uint16(20))
+	assert.Equal(t, binary.BigEndian.Uint16(chunks[4].data[22:]), uint16(100))
+
+	// the viewers without animation support show the first frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	assert.Equal(t, err, nil)
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, r, uint32(0xffff))
+
+	assert.Equal(t, apng.addFrame(image.NewRGBA(image.Rect(0, 0, 4, 4)), 100) != nil, true)
+	assert.Equal(t, new(apngEncoder).encode(&bytes.Buffer{}) != nil, true)
+
+	// the longer delays are clamped
+	var long apngEncoder
+	assert.Equal(t, long.addFrame(frames[0], 1000000), nil)
+	buf.Reset()
+	assert.Equal(t, long.encode(&buf), nil)
+	chunks, _ = readPNGChunks(buf.Bytes())
+	assert.Equal(t, binary.BigEndian.Uint16(chunks[2].data[20:]), uint16(65535))
+}
+
+func TestRenderMapTimeline(t *testing.T) {
+	dir := t.TempDir()
+
+	mbtilesFile := emptyMBTiles(t)
+
+	cfg := LogbookConfig{MBTilesFile: mbtilesFile, MapWidth: 200, MapHeight: 150, MapTimeline: "year"}
+
+	fileName := filepath.Join(dir, "timeline.gif")
+	frames, err := renderMapTimeline(fileName, cfg, testMapRows, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, frames, 1)
+
+	f, err := os.Open(fileName)
+	assert.Equal(t, err, nil)
+	defer f.Close()
+
+	animation, err := gif.DecodeAll(f)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(animation.Image), 1)
+	assert.Equal(t, animation.Delay[0], timelineFrameDelay*timelineLastFrameFactor/10)
+
+	_, err = renderMapTimeline(filepath.Join(dir, "timeline.svg"), cfg, testMapRows, testAirports)
+	assert.Equal(t, err != nil, true)
+
+	cfg.MapFrameDelay = 70000
+	_, err = renderMapTimeline(filepath.Join(dir, "timeline.png"), cfg, testMapRows, testAirports)
+	assert.Equal(t, err, fmt.Errorf("wrong frame delay 70000, should be from 1 to 60000 milliseconds"))
+}
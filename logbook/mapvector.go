@@ -0,0 +1,375 @@
+package logbook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// resolution of the map page embedded in the exported logbook
+var mapPageDPI = 150.0
+
+// svgColor returns the colour and the opacity for the svg attributes
+func svgColor(c color.Color) (string, float64) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B), float64(n.A) / 0xff
+}
+
+// encodePNG returns the image encoded to png
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeMapSVG writes the map with the embedded raster basemap and the vector countries,
+// routes, markers and legend. The physical size of the map is set by its resolution
+//
+// w io.Writer - output
+//
+// scene mapScene - map objects
+//
+// basemap image.Image - rendered tiles
+func writeMapSVG(w io.Writer, scene mapScene, basemap image.Image) error {
+	data, err := encodePNG(basemap)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%.3fin" height="%.3fin" viewBox="0 0 %d %d">`+"\n",
+		float64(scene.width)/scene.dpi, float64(scene.height)/scene.dpi, scene.width, scene.height)
+	fmt.Fprintf(b, `<image width="%d" height="%d" xlink:href="data:image/png;base64,%s"/>`+"\n",
+		scene.width, scene.height, base64.StdEncoding.EncodeToString(data))
+
+	if countries := scene.shadedCountries(); len(countries) > 0 {
+		fmt.Fprintf(b, `<g stroke="none" fill-rule="evenodd">`+"\n")
+		for _, country := range countries {
+			var path []string
+			for _, ring := range country.rings {
+				for i, point := range ring {
+					command := "L"
+					if i == 0 {
+						command = "M"
+					}
+					path = append(path, fmt.Sprintf("%s%.2f,%.2f", command, point[0], point[1]))
+				}
+				path = append(path, "Z")
+			}
+
+			fill, opacity := svgColor(country.color)
+			fmt.Fprintf(b, `<path d="%s" fill="%s" fill-opacity="%.2f"/>`+"\n", strings.Join(path, " "), fill, opacity)
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	fmt.Fprintf(b, `<g fill="none" stroke-linecap="round" stroke-linejoin="round">`+"\n")
+	for _, line := range scene.lines {
+		var points []string
+		for _, point := range line.points {
+			x, y := scene.project(point)
+			points = append(points, fmt.Sprintf("%.2f,%.2f", x, y))
+		}
+
+		stroke, opacity := svgColor(line.color)
+		fmt.Fprintf(b, `<polyline points="%s" stroke="%s" stroke-opacity="%.2f" stroke-width="%.2f"/>`+"\n",
+			strings.Join(points, " "), stroke, opacity, line.width)
+	}
+	fmt.Fprintf(b, "</g>\n")
+
+	if len(scene.heat) > 0 {
+		heatmap, err := encodePNG(drawHeatmap(scene))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(b, `<image width="%d" height="%d" xlink:href="data:image/png;base64,%s"/>`+"\n",
+			scene.width, scene.height, base64.StdEncoding.EncodeToString(heatmap))
+	}
+
+	fmt.Fprintf(b, `<g stroke="#000000" stroke-width="1" stroke-linejoin="round">`+"\n")
+	for _, marker := range scene.markers {
+		x, y := scene.project(marker.position)
+
+		var points []string
+		for _, point := range markerOutline(x, y, marker.size) {
+			points = append(points, fmt.Sprintf("%.2f,%.2f", point[0], point[1]))
+		}
+
+		fill, opacity := svgColor(marker.color)
+		fmt.Fprintf(b, `<polygon points="%s" fill="%s" fill-opacity="%.2f"/>`+"\n", strings.Join(points, " "), fill, opacity)
+	}
+	fmt.Fprintf(b, "</g>\n")
+
+	if len(scene.labels) > 0 {
+		fmt.Fprintf(b, `<g font-family="Liberation Sans Narrow, Arial Narrow, sans-serif" font-size="%.2f">`+"\n", scene.labels[0].fontSize)
+		for _, label := range scene.labels {
+			fmt.Fprintf(b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#ffffff" fill-opacity="0.82"/>`+"\n",
+				label.box.x, label.box.y, label.box.width, label.box.height)
+			fmt.Fprintf(b, `<text x="%.2f" y="%.2f">%s</text>`+"\n", label.textX, label.textY, html.EscapeString(label.text))
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	if len(scene.legend) > 0 {
+		l, err := newLegendLayout(scene.legend, scene.scale(), scene.height)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(b, `<g font-family="Liberation Sans Narrow, Arial Narrow, sans-serif" font-size="%.2f">`+"\n", l.fontSize)
+		fmt.Fprintf(b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#ffffff" fill-opacity="0.82"/>`+"\n", l.x, l.y, l.width, l.height)
+
+		for i, line := range l.lines {
+			textX := l.x + l.padding
+			lineY := l.lineY(i)
+
+			if line.color != nil {
+				stroke, opacity := svgColor(line.color)
+				fmt.Fprintf(b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-opacity="%.2f" stroke-width="%.2f"/>`+"\n",
+					textX, lineY+l.lineHeight/2, textX+l.sampleWidth, lineY+l.lineHeight/2, stroke, opacity, l.fontSize/4)
+				textX += l.sampleWidth + l.padding
+			}
+
+			fmt.Fprintf(b, `<text x="%.2f" y="%.2f">%s</text>`+"\n", textX, lineY+l.textOffset, html.EscapeString(line.text))
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	fmt.Fprintf(b, "</svg>\n")
+
+	return b.Flush()
+}
+
+// saveMapSVG saves the map to the svg file
+func saveMapSVG(fileName string, scene mapScene, basemap image.Image) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if err := writeMapSVG(f, scene, basemap); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// pdfColor sets the opacity for the following pdf drawing and returns the colour components
+func pdfColor(pdf *gofpdf.Fpdf, c color.Color) (int, int, int) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	pdf.SetAlpha(float64(n.A)/0xff, "Normal")
+	return int(n.R), int(n.G), int(n.B)
+}
+
+// drawMapPDF draws the map on the current pdf page with the raster basemap and the vector
+// routes, markers and legend. The pdf fonts should be already loaded
+//
+// pdf *gofpdf.Fpdf - pdf object
+//
+// scene mapScene - map objects
+//
+// basemap image.Image - rendered tiles
+//
+// left, top float64 - position of the map on the page in mm
+func drawMapPDF(pdf *gofpdf.Fpdf, scene mapScene, basemap image.Image, left float64, top float64) error {
+	data, err := encodePNG(basemap)
+	if err != nil {
+		return err
+	}
+
+	// map pixels to mm
+	k := 25.4 / scene.dpi
+	px := func(x, y float64) (float64, float64) {
+		return left + x*k, top + y*k
+	}
+
+	imageName := fmt.Sprintf("map-%d", pdf.PageNo())
+	pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(data))
+	pdf.ImageOptions(imageName, left, top, float64(scene.width)*k, float64(scene.height)*k, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	for _, country := range scene.shadedCountries() {
+		pdf.SetFillColor(pdfColor(pdf, country.color))
+		for _, ring := range country.rings {
+			for i, point := range ring {
+				x, y := px(point[0], point[1])
+				if i == 0 {
+					pdf.MoveTo(x, y)
+				} else {
+					pdf.LineTo(x, y)
+				}
+			}
+			pdf.ClosePath()
+		}
+		pdf.DrawPath("F*")
+	}
+
+	pdf.SetLineCapStyle("round")
+	pdf.SetLineJoinStyle("round")
+
+	for _, line := range scene.lines {
+		pdf.SetDrawColor(pdfColor(pdf, line.color))
+		pdf.SetLineWidth(line.width * k)
+
+		for i, point := range line.points {
+			x, y := px(scene.project(point))
+			if i == 0 {
+				pdf.MoveTo(x, y)
+			} else {
+				pdf.LineTo(x, y)
+			}
+		}
+		pdf.DrawPath("D")
+	}
+
+	pdf.SetAlpha(1, "Normal")
+
+	if len(scene.heat) > 0 {
+		heatmap, err := encodePNG(drawHeatmap(scene))
+		if err != nil {
+			return err
+		}
+
+		heatmapName := fmt.Sprintf("heatmap-%d", pdf.PageNo())
+		pdf.RegisterImageOptionsReader(heatmapName, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(heatmap))
+		pdf.ImageOptions(heatmapName, left, top, float64(scene.width)*k, float64(scene.height)*k, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetLineWidth(k)
+
+	for _, marker := range scene.markers {
+		x, y := scene.project(marker.position)
+
+		var points []gofpdf.PointType
+		for _, point := range markerOutline(x, y, marker.size) {
+			x, y := px(point[0], point[1])
+			points = append(points, gofpdf.PointType{X: x, Y: y})
+		}
+
+		pdf.SetFillColor(pdfColor(pdf, marker.color))
+		pdf.Polygon(points, "FD")
+	}
+
+	for _, label := range scene.labels {
+		pdf.SetAlpha(0.82, "Normal")
+		pdf.SetFillColor(0xff, 0xff, 0xff)
+		x, y := px(label.box.x, label.box.y)
+		pdf.Rect(x, y, label.box.width*k, label.box.height*k, "F")
+
+		pdf.SetAlpha(1, "Normal")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("LiberationSansNarrow-Regular", "", label.fontSize*k/25.4*72)
+		x, y = px(label.textX, label.textY)
+		pdf.Text(x, y, label.text)
+	}
+
+	if len(scene.legend) > 0 {
+		l, err := newLegendLayout(scene.legend, scene.scale(), scene.height)
+		if err != nil {
+			return err
+		}
+
+		pdf.SetAlpha(0.82, "Normal")
+		pdf.SetFillColor(0xff, 0xff, 0xff)
+		x, y := px(l.x, l.y)
+		pdf.Rect(x, y, l.width*k, l.height*k, "F")
+
+		// font size in points
+		pdf.SetFont("LiberationSansNarrow-Regular", "", l.fontSize*k/25.4*72)
+
+		for i, line := range l.lines {
+			textX := l.x + l.padding
+			lineY := l.lineY(i)
+
+			if line.color != nil {
+				pdf.SetDrawColor(pdfColor(pdf, line.color))
+				pdf.SetLineWidth(l.fontSize / 4 * k)
+				x1, y1 := px(textX, lineY+l.lineHeight/2)
+				x2, y2 := px(textX+l.sampleWidth, lineY+l.lineHeight/2)
+				pdf.Line(x1, y1, x2, y2)
+				textX += l.sampleWidth + l.padding
+			}
+
+			pdf.SetAlpha(1, "Normal")
+			pdf.SetTextColor(0, 0, 0)
+			x, y := px(textX, lineY+l.textOffset)
+			pdf.Text(x, y, line.text)
+		}
+	}
+
+	pdf.SetAlpha(1, "Normal")
+	pdf.SetLineCapStyle("butt")
+	pdf.SetLineJoinStyle("miter")
+
+	return pdf.Error()
+}
+
+// saveMapPDF saves the map to the pdf file with the page size of the map
+func saveMapPDF(fileName string, scene mapScene, basemap image.Image) error {
+	k := 25.4 / scene.dpi
+
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		UnitStr: "mm",
+		Size:    gofpdf.SizeType{Wd: float64(scene.width) * k, Ht: float64(scene.height) * k},
+	})
+	LoadFonts(pdf)
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	if err := drawMapPDF(pdf, scene, basemap, 0, 0); err != nil {
+		return err
+	}
+
+	return pdf.OutputFileAndClose(fileName)
+}
+
+// addMapPage adds the page with the map of all flights to the exported logbook, the map
+// fills the page inside the margins
+//
+// pdf *gofpdf.Fpdf - pdf object
+//
+// logbookConfig LogbookConfig - logbook config with the map settings
+//
+// rows [][]interface{} - logbook rows
+func addMapPage(pdf *gofpdf.Fpdf, logbookConfig LogbookConfig, rows [][]interface{}) error {
+	airports, err := loadAirportsDB(logbookConfig)
+	if err != nil {
+		return fmt.Errorf("cannot load airports database: %v", err)
+	}
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, _, _ := pdf.GetMargins()
+
+	logbookConfig.MapDPI = mapPageDPI
+	logbookConfig.MapWidth = int((pageWidth - 2*left) / 25.4 * mapPageDPI)
+	logbookConfig.MapHeight = int((pageHeight - 2*top) / 25.4 * mapPageDPI)
+
+	scene, err := buildMapScene(logbookConfig, rows, airports)
+	if err != nil {
+		return err
+	}
+
+	basemap, err := renderMapImage(scene, logbookConfig, false)
+	if err != nil {
+		return err
+	}
+
+	pdf.AddPage()
+	return drawMapPDF(pdf, scene, basemap, left, top)
+}
@@ -0,0 +1,147 @@
+package logbook
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/s2"
+)
+
+// sun elevation at the end of the evening civil twilight and the start of the morning civil twilight
+var civilTwilightElevation = -6.0
+
+// flightTimes returns the departure and arrival times of the record in UTC.
+// In case the arrival time is less than departure time the flight crosses midnight
+func flightTimes(record logbookRecord) (departure time.Time, arrival time.Time, err error) {
+	if record.departure.time == "" || record.arrival.time == "" {
+		return departure, arrival, fmt.Errorf("departure or arrival time is not set")
+	}
+
+	departure, err = time.Parse(dateLayout+" 1504", record.date+" "+record.departure.time)
+	if err != nil {
+		return departure, arrival, fmt.Errorf("wrong departure date or time: %v", err)
+	}
+
+	arrival, err = time.Parse(dateLayout+" 1504", record.date+" "+record.arrival.time)
+	if err != nil {
+		return departure, arrival, fmt.Errorf("wrong arrival date or time: %v", err)
+	}
+
+	if arrival.Before(departure) {
+		arrival = arrival.Add(24 * time.Hour)
+	}
+
+	return departure, arrival, nil
+}
+
+// hasFlightTimes returns if the record is a flight with the departure or arrival time set, the
+// simulator sessions and the records without times cannot be checked against the times
+func hasFlightTimes(record logbookRecord) bool {
+	return record.sim.name == "" && (record.departure.time != "" || record.arrival.time != "")
+}
+
+// sunElevation returns the sun elevation in degrees for the position and time.
+// It's a simplified NOAA algorithm, the accuracy is about 0.01 degree
+func sunElevation(t time.Time, position s2.LatLng) float64 {
+	rad := math.Pi / 180
+
+	// days since J2000.0
+	n := float64(t.UTC().UnixNano())/float64(24*time.Hour) + 2440587.5 - 2451545.0
+
+	meanLongitude := math.Mod(280.460+0.9856474*n, 360)
+	meanAnomaly := math.Mod(357.528+0.9856003*n, 360) * rad
+	eclipticLongitude := (meanLongitude + 1.915*math.Sin(meanAnomaly) + 0.020*math.Sin(2*meanAnomaly)) * rad
+	obliquity := (23.439 - 0.0000004*n) * rad
+
+	rightAscension := math.Atan2(math.Cos(obliquity)*math.Sin(eclipticLongitude), math.Cos(eclipticLongitude))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(eclipticLongitude))
+
+	siderealTime := math.Mod(280.46061837+360.98564736629*n, 360) * rad
+	hourAngle := siderealTime + position.Lng.Radians() - rightAscension
+
+	lat := position.Lat.Radians()
+	elevation := math.Asin(math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle))
+
+	return elevation / rad
+}
+
+// calculateNightTime returns the night time of the flight. The position of the aircraft is
+// interpolated along the great circle route between airports and for every minute of the
+// flight it's checked if the sun is below the civil twilight elevation (EASA definition)
+//
+// record logbookRecord - logbook record
+//
+// airports airportsDB - airports database
+func calculateNightTime(record logbookRecord, airports airportsDB) (time.Duration, error) {
+	departure, arrival, err := flightTimes(record)
+	if err != nil {
+		return 0, err
+	}
+
+	from, ok := airports.lookup(record.departure.place)
+	if !ok {
+		return 0, fmt.Errorf("unknown airport %s", record.departure.place)
+	}
+
+	to, ok := airports.lookup(record.arrival.place)
+	if !ok {
+		return 0, fmt.Errorf("unknown airport %s", record.arrival.place)
+	}
+
+	a := s2.PointFromLatLng(from.latLng())
+	b := s2.PointFromLatLng(to.latLng())
+
+	duration := arrival.Sub(departure)
+	minutes := int(duration / time.Minute)
+
+	var night time.Duration
+	for i := 0; i < minutes; i++ {
+		// the middle of the minute
+		fraction := (float64(i) + 0.5) / float64(minutes)
+		position := s2.LatLngFromPoint(s2.Interpolate(fraction, a, b))
+		moment := departure.Add(time.Duration(i)*time.Minute + 30*time.Second)
+
+		if sunElevation(moment, position) < civilTwilightElevation {
+			night += time.Minute
+		}
+	}
+
+	return night, nil
+}
+
+// checkNightTime returns the check which compares the night time in the logbook with the calculated one
+//
+// airports airportsDB - airports database
+//
+// tolerance time.Duration - allowed difference between the logbook and calculated times
+func checkNightTime(airports airportsDB, tolerance time.Duration) recordCheck {
+	return func(record logbookRecord) string {
+		if record.departure.place == "" || record.time.total.time == 0 || !hasFlightTimes(record) {
+			return ""
+		}
+
+		night, err := calculateNightTime(record, airports)
+		if err != nil {
+			return fmt.Sprintf("cannot calculate night time: %v", err)
+		}
+
+		if diff := night - record.time.night.time; diff > tolerance || diff < -tolerance {
+			calculated := logbookTime{time: night}
+			return fmt.Sprintf("night time is %s, calculated %s", record.time.night.GetTime(true), calculated.GetTime(true))
+		}
+
+		return ""
+	}
+}
+
+// fillNightTime sets the calculated night time in case it's not set in the logbook record
+func fillNightTime(record *logbookRecord, airports airportsDB) {
+	if record.time.night.time != 0 || record.departure.place == "" {
+		return
+	}
+
+	if night, err := calculateNightTime(*record, airports); err == nil {
+		record.time.night.time = night
+	}
+}
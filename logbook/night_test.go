@@ -0,0 +1,75 @@
+package logbook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/magiconair/properties/assert"
+)
+
+var testAirports = newAirportsDB(map[string]Airport{
+	"LEMG": {IATA: "AGP", Lat: 36.6749, Lon: -4.49911},
+	"LKPR": {IATA: "PRG", Lat: 50.1008, Lon: 14.26},
+	"LKPD": {IATA: "PED", Lat: 50.0134, Lon: 15.7386},
+})
+
+func TestSunElevation(t *testing.T) {
+	prague := s2.LatLngFromDegrees(50.1, 14.26)
+
+	noon := sunElevation(time.Date(2021, 6, 21, 11, 0, 0, 0, time.UTC), prague)
+	assert.Equal(t, noon > 62 && noon < 64, true)
+
+	midnight := sunElevation(time.Date(2021, 6, 21, 23, 0, 0, 0, time.UTC), prague)
+	assert.Equal(t, midnight > -18 && midnight < -15, true)
+}
+
+func TestFlightTimes(t *testing.T) {
+	var record logbookRecord
+	record.date = "31/12/2021"
+	record.departure.time = "2330"
+	record.arrival.time = "0115"
+
+	departure, arrival, err := flightTimes(record)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, arrival.Sub(departure), 105*time.Minute)
+	assert.Equal(t, arrival.Format(dateLayout), "01/01/2022")
+}
+
+func TestCalculateNightTime(t *testing.T) {
+	record := parseRecord([]interface{}{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35"})
+
+	night, err := calculateNightTime(record, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, night, 215*time.Minute)
+
+	// daylight departure, the sun goes below the civil twilight on the way
+	record = parseRecord([]interface{}{"08/10/2021", "LKPD", "1510", "LEMG", "1845", "B738", "OK-TVS", "", "03:35", "03:35", "03:35"})
+	night, err = calculateNightTime(record, testAirports)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, night > 30*time.Minute && night < 50*time.Minute, true)
+
+	record.arrival.place = "XXXX"
+	_, err = calculateNightTime(record, testAirports)
+	assert.Equal(t, err != nil, true)
+
+	// check and fill
+	record = parseRecord([]interface{}{"08/10/2021", "LEMG", "1930", "LKPR", "2305", "B738", "OK-TVS", "", "03:35", "03:35", "03:35", "", "1", "01:00"})
+	assert.Equal(t, checkNightTime(testAirports, 5*time.Minute)(record), "night time is 1:00, calculated 3:35")
+
+	record.time.night.time = 0
+	fillNightTime(&record, testAirports)
+	assert.Equal(t, record.time.night.GetTime(), "3:35")
+	assert.Equal(t, checkNightTime(testAirports, 5*time.Minute)(record), "")
+
+	// the records without times and the simulator sessions are skipped
+	record = parseRecord([]interface{}{"08/10/2021", "LEMG", "", "LKPR", "", "B738", "OK-TVS", "", "03:35", "03:35", "03:35"})
+	assert.Equal(t, checkNightTime(testAirports, 5*time.Minute)(record), "")
+
+	record = parseRecord([]interface{}{"06/03/2020", "LKPR", "1000", "LKPR", "1130", "", "", "", "", "", "01:30", "", "", "", "", "", "", "", "", "ULT-28", "01:30"})
+	assert.Equal(t, checkNightTime(testAirports, 5*time.Minute)(record), "")
+
+	// the times are set, but the night time cannot be calculated
+	record = parseRecord([]interface{}{"08/10/2021", "LEMG", "1930", "LKPR", "", "B738", "OK-TVS", "", "03:35", "03:35", "03:35"})
+	assert.Equal(t, checkNightTime(testAirports, 5*time.Minute)(record), "cannot calculate night time: departure or arrival time is not set")
+}
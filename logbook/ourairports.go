@@ -0,0 +1,166 @@
+package logbook
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeout of the airports database download, the OurAirports file is about 10 MB
+var airportsDownloadTimeout = 5 * time.Minute
+
+// airportsDBFile returns the file name of the airports database updated from OurAirports
+func airportsDBFile(logbookConfig LogbookConfig) (string, error) {
+	if logbookConfig.AirportsDB != "" {
+		return logbookConfig.AirportsDB, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "logbook", "airports.json"), nil
+}
+
+// openAirportsSource opens the local file or downloads it in case the source is URL
+func openAirportsSource(source string) (io.ReadCloser, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: airportsDownloadTimeout}
+
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("cannot download %s: %v", source, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("cannot download %s: %s", source, resp.Status)
+		}
+
+		return resp.Body, nil
+	}
+
+	return os.Open(source)
+}
+
+// parseOurAirportsCSV converts OurAirports airports.csv to the airports list. The closed
+// airports and the ones without ident are skipped
+func parseOurAirportsCSV(r io.Reader) (map[string]Airport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read csv header: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")] = i
+	}
+
+	for _, name := range []string{"ident", "type", "name", "latitude_deg", "longitude_deg"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is not found, it's not OurAirports airports.csv file", name)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	airports := make(map[string]Airport)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read csv file: %v", err)
+		}
+
+		ident := strings.ToUpper(field(row, "ident"))
+		if ident == "" || field(row, "type") == "closed" {
+			continue
+		}
+
+		lat, err1 := strconv.ParseFloat(field(row, "latitude_deg"), 64)
+		lon, err2 := strconv.ParseFloat(field(row, "longitude_deg"), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		elevation, _ := strconv.Atoi(field(row, "elevation_ft"))
+
+		airports[ident] = Airport{
+			ICAO:      ident,
+			IATA:      field(row, "iata_code"),
+			Name:      field(row, "name"),
+			City:      field(row, "municipality"),
+			Region:    field(row, "iso_region"),
+			Country:   field(row, "iso_country"),
+			Elevation: elevation,
+			Lat:       lat,
+			Lon:       lon,
+		}
+	}
+
+	return airports, nil
+}
+
+// UpdateAirports converts OurAirports airports.csv file to the airports database
+// in the user config directory, it's used instead of the embedded one
+func UpdateAirports(logbookConfig LogbookConfig, source string) {
+	r, err := openAirportsSource(source)
+	if err != nil {
+		log.Fatalf("Cannot open airports file: %v", err)
+	}
+	defer r.Close()
+
+	airports, err := parseOurAirportsCSV(r)
+	if err != nil {
+		log.Fatalf("Cannot parse airports file: %v", err)
+	}
+
+	// OurAirports doesn't have timezones, so they are taken from the embedded database
+	if embedded, err := loadEmbeddedAirportsDB(); err == nil {
+		for code, airport := range airports {
+			if known, ok := embedded.lookup(code); ok && airport.TZ == "" {
+				airport.TZ = known.TZ
+				airports[code] = airport
+			}
+		}
+	}
+
+	fileName, err := airportsDBFile(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot find config directory: %v", err)
+	}
+
+	data, err := json.Marshal(airports)
+	if err != nil {
+		log.Fatalf("Cannot save airports database: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		log.Fatalf("Cannot save airports database: %v", err)
+	}
+
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		log.Fatalf("Cannot save airports database: %v", err)
+	}
+
+	fmt.Printf("%d airports have been saved to %s\n", len(airports), fileName)
+}
@@ -0,0 +1,76 @@
+package logbook
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+var testOurAirportsCSV = `"id","ident","type","name","latitude_deg","longitude_deg","elevation_ft","continent","iso_country","iso_region","municipality","scheduled_service","gps_code","iata_code","local_code","home_link","wikipedia_link","keywords"
+2212,"EDDF","large_airport","Frankfurt am Main Airport",50.036249,8.559294,364,"EU","DE","DE-HE","Frankfurt am Main","yes","EDDF","FRA",,"https://www.frankfurt-airport.com/","",""
+29441,"LKSZ","small_airport","Sazená Airfield",50.3247,14.2589,761,"EU","CZ","CZ-ST","Sazená","no","LKSZ",,,,"",""
+300,"XX01","closed","Closed Airfield",10.0,10.0,,"EU","CZ","CZ-ST","","no",,,,,"",""
+`
+
+func TestUpdateAirports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testOurAirportsCSV)
+	}))
+	defer server.Close()
+
+	logbookConfig := LogbookConfig{AirportsDB: filepath.Join(t.TempDir(), "airports.json")}
+
+	// the embedded database is used until the update
+	airports, err := loadAirportsDB(logbookConfig)
+	assert.Equal(t, err, nil)
+	_, ok := airports.lookup("LKSZ")
+	assert.Equal(t, ok, false)
+
+	UpdateAirports(logbookConfig, server.URL+"/airports.csv")
+
+	airports, err = loadAirportsDB(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(airports.airports), 2)
+
+	airport, ok := airports.lookup("LKSZ")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.Name, "Sazená Airfield")
+	assert.Equal(t, airport.Elevation, 761)
+	assert.Equal(t, airport.Region, "CZ-ST")
+
+	// the timezone is taken from the embedded database
+	airport, ok = airports.lookup("FRA")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, airport.TZ, "Europe/Berlin")
+
+	_, ok = airports.lookup("XX01")
+	assert.Equal(t, ok, false)
+}
+
+func TestOpenAirportsSource(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.csv" {
+			http.NotFound(w, r)
+			return
+		}
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	_, err := openAirportsSource(server.URL + "/missing.csv")
+	assert.Equal(t, err, fmt.Errorf("cannot download %s/missing.csv: 404 Not Found", server.URL))
+
+	// the stalled download is stopped
+	airportsDownloadTimeout = 100 * time.Millisecond
+	defer func() { airportsDownloadTimeout = 5 * time.Minute }()
+
+	_, err = openAirportsSource(server.URL + "/airports.csv")
+	assert.Equal(t, err != nil, true)
+}
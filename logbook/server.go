@@ -0,0 +1,557 @@
+package logbook
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed web/*
+var webContent embed.FS
+
+// number of the flights on one page of the web ui
+var flightsPerPage = 50
+
+// flightView is the logbook record formatted for the web ui
+type flightView struct {
+	Date          string
+	Departure     string
+	DepartureTime string
+	Arrival       string
+	ArrivalTime   string
+	Model         string
+	Reg           string
+	Total         string
+	Night         string
+	IFR           string
+	PIC           string
+	Landings      string
+	PICName       string
+	Simulator     string
+	Remarks       string
+}
+
+// totalsView is the logbook totals formatted for the web ui
+type totalsView struct {
+	Flights         int
+	Total           string
+	Night           string
+	IFR             string
+	PIC             string
+	Simulator       string
+	DayLandings     int
+	NightLandings   int
+	Distance        string
+	Airports        int
+	MissingAirports []string
+	Countries       int
+	FirstFlight     string
+	LastFlight      string
+}
+
+// yearView is the bar of the flight time per year chart
+type yearView struct {
+	Year    int
+	Total   string
+	Flights int
+	Width   float64
+}
+
+// pageView is the data of the web ui page
+type pageView struct {
+	Title    string
+	Page     string
+	Version  int
+	Error    string
+	Loaded   string
+	Totals   totalsView
+	Flights  []flightView
+	PageNum  int
+	PrevPage int
+	NextPage int
+	Pages    int
+	Years    []yearView
+	Sections []statsSection
+}
+
+// statsSection is the text table of the stats command shown in the web ui
+type statsSection struct {
+	Title string
+	Text  string
+}
+
+// logbookServer serves the parsed logbook. The records are reloaded in case the logbook
+// files change, the generated pdf and map are kept till the next reload
+type logbookServer struct {
+	config    LogbookConfig
+	airports  airportsDB
+	templates map[string]*template.Template
+	now       func() time.Time
+
+	mu          sync.Mutex
+	rows        [][]interface{}
+	registry    aircraftRegistry
+	err         error
+	loaded      time.Time
+	fingerprint string
+	loading     bool
+	version     int
+	pdf         []byte
+	mapImage    []byte
+}
+
+// newLogbookServer returns the server with the loaded logbook, the load errors are shown on
+// the pages and the logbook is reloaded after the files are fixed
+func newLogbookServer(logbookConfig LogbookConfig) (*logbookServer, error) {
+	airports, err := loadAirportsDB(logbookConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load airports database: %v", err)
+	}
+
+	s := &logbookServer{
+		config:    logbookConfig,
+		airports:  airports,
+		templates: make(map[string]*template.Template),
+		now:       time.Now,
+	}
+
+	for _, page := range []string{"index", "flights", "stats"} {
+		t, err := template.ParseFS(webContent, "web/layout.html", "web/"+page+".html")
+		if err != nil {
+			return nil, err
+		}
+		s.templates[page] = t
+	}
+
+	s.refresh()
+
+	return s, nil
+}
+
+// sourceFiles returns the local files of the logbook, the google spreadsheets are not
+// reloaded automatically
+func sourceFiles(logbookConfig LogbookConfig) []string {
+	configs := []LogbookConfig{logbookConfig}
+	if len(logbookConfig.Sources) > 0 {
+		configs = sourceConfigs(logbookConfig)
+	}
+
+	var files []string
+	for _, config := range configs {
+		if config.SourceType != "google" && config.FileName != "" {
+			files = append(files, config.FileName)
+		}
+	}
+
+	return files
+}
+
+// sourceFingerprint returns the modification times and sizes of the logbook files
+func sourceFingerprint(files []string) string {
+	var parts []string
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			parts = append(parts, file+" missing")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %d %d", file, info.ModTime().UnixNano(), info.Size()))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// refresh reloads the logbook in case it's not loaded yet or the files are changed. The
+// previous records are kept in case the logbook cannot be parsed. The logbook is read
+// without the lock, so the other pages are not blocked by the slow source, e.g. google
+// spreadsheet, and they get the previous records until the reload is finished
+func (s *logbookServer) refresh() {
+	fingerprint := sourceFingerprint(sourceFiles(s.config))
+
+	s.mu.Lock()
+	if s.loading || (s.version > 0 && fingerprint == s.fingerprint) {
+		s.mu.Unlock()
+		return
+	}
+	s.loading = true
+	s.mu.Unlock()
+
+	rows, err := getLogbookDump(s.config)
+	var registry aircraftRegistry
+	if err == nil {
+		registry, err = loadAircraftRegistry(s.config)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loading = false
+	s.fingerprint = fingerprint
+	s.version++
+
+	s.err = err
+	if err != nil {
+		log.Printf("Cannot reload logbook: %v", err)
+		return
+	}
+
+	s.rows = rows
+	s.registry = registry
+	s.loaded = time.Now()
+	s.pdf = nil
+	s.mapImage = nil
+}
+
+// handler returns the handler with all pages of the web ui and the api, the tokens are
+// required for all of them in case there are api tokens in the config
+func (s *logbookServer) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/flights", s.handleFlights)
+	mux.HandleFunc("/stats", s.handleStats)
+	mux.HandleFunc("/logbook.pdf", s.handlePDF)
+	mux.HandleFunc("/map.png", s.handleMap)
+	mux.HandleFunc("/version", s.handleVersion)
+	mux.Handle("/api/", s.apiHandler())
+
+	return s.requireToken(mux)
+}
+
+// newPage returns the page view with the common fields, the logbook is reloaded before
+func (s *logbookServer) newPage(page string, title string) (pageView, [][]interface{}) {
+	s.refresh()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	view := pageView{
+		Title:   title,
+		Page:    page,
+		Version: s.version,
+		Loaded:  s.loaded.Format("2006-01-02 15:04:05"),
+	}
+	if s.err != nil {
+		view.Error = s.err.Error()
+	}
+
+	return view, s.rows
+}
+
+// render executes the page template
+func (s *logbookServer) render(w http.ResponseWriter, page string, view pageView) {
+	var buf bytes.Buffer
+	if err := s.templates[page].ExecuteTemplate(&buf, "layout", view); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// newFlightView formats the logbook record for the web ui
+func newFlightView(record logbookRecord) flightView {
+	view := flightView{
+		Date:          record.date,
+		Departure:     record.departure.place,
+		DepartureTime: record.departure.time,
+		Arrival:       record.arrival.place,
+		ArrivalTime:   record.arrival.time,
+		Model:         record.aircraft.model,
+		Reg:           record.aircraft.reg,
+		Total:         record.time.total.GetTime(),
+		Night:         record.time.night.GetTime(),
+		IFR:           record.time.ifr.GetTime(),
+		PIC:           record.time.pic.GetTime(),
+		PICName:       record.pic,
+		Remarks:       record.remarks,
+	}
+
+	if record.landings.day != 0 || record.landings.night != 0 {
+		view.Landings = fmt.Sprintf("%d/%d", record.landings.day, record.landings.night)
+	}
+	if record.sim.name != "" {
+		view.Simulator = fmt.Sprintf("%s %s", record.sim.name, record.sim.time.GetTime())
+	}
+
+	return view
+}
+
+// newTotalsView returns the logbook totals for the web ui
+func (s *logbookServer) newTotalsView(rows [][]interface{}) totalsView {
+	var totals logbookTotalRecord
+	var view totalsView
+
+	var first, last time.Time
+	for _, row := range rows {
+		record := parseRecord(row)
+		totals = calculateTotals(totals, record)
+
+		date, err := time.Parse(dateLayout, record.date)
+		if err != nil {
+			continue
+		}
+		view.Flights++
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if date.After(last) {
+			last = date
+		}
+	}
+
+	view.Total = totals.time.total.GetTime(true)
+	view.Night = totals.time.night.GetTime(true)
+	view.IFR = totals.time.ifr.GetTime(true)
+	view.PIC = totals.time.pic.GetTime(true)
+	view.Simulator = totals.sim.time.GetTime(true)
+	view.DayLandings = totals.landings.day
+	view.NightLandings = totals.landings.night
+	view.Distance = formatDistance(calculateDistanceStats(rows, s.airports).total)
+	view.Countries = len(collectPlaceTotals(rows, s.airports, false))
+
+	visits, missing := collectAirportVisits(rows, s.airports)
+	view.Airports = len(visits) + len(missing)
+	view.MissingAirports = missing
+
+	if !first.IsZero() {
+		view.FirstFlight = first.Format(dateLayout)
+		view.LastFlight = last.Format(dateLayout)
+	}
+
+	return view
+}
+
+// handleIndex shows the totals and the map
+func (s *logbookServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	view, rows := s.newPage("index", "Logbook")
+	view.Totals = s.newTotalsView(rows)
+
+	s.render(w, "index", view)
+}
+
+// handleFlights shows one page of the flights, the most recent flights are on the first page
+func (s *logbookServer) handleFlights(w http.ResponseWriter, r *http.Request) {
+	view, rows := s.newPage("flights", "Flights")
+
+	// the records are in the chronological order in the normal logbook
+	records := make([]logbookRecord, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, parseRecord(row))
+	}
+	if !s.config.Reverse {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+
+	view.Pages = (len(records) + flightsPerPage - 1) / flightsPerPage
+	if view.Pages == 0 {
+		view.Pages = 1
+	}
+
+	view.PageNum = 1
+	if page := r.URL.Query().Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 || n > view.Pages {
+			http.Error(w, fmt.Sprintf("wrong page %s, should be from 1 to %d", page, view.Pages), http.StatusBadRequest)
+			return
+		}
+		view.PageNum = n
+	}
+	view.PrevPage, view.NextPage = view.PageNum-1, view.PageNum+1
+
+	start := (view.PageNum - 1) * flightsPerPage
+	end := start + flightsPerPage
+	if end > len(records) {
+		end = len(records)
+	}
+	for _, record := range records[start:end] {
+		view.Flights = append(view.Flights, newFlightView(record))
+	}
+
+	s.render(w, "flights", view)
+}
+
+// handleStats shows the flight time per year and the tables of the stats command
+func (s *logbookServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	view, rows := s.newPage("stats", "Stats")
+	view.Totals = s.newTotalsView(rows)
+
+	s.mu.Lock()
+	registry := s.registry
+	s.mu.Unlock()
+
+	// flight time per year
+	flights := make(map[int]int)
+	times := make(map[int]time.Duration)
+	var maxTime time.Duration
+	for _, row := range rows {
+		record := parseRecord(row)
+		date, err := time.Parse(dateLayout, record.date)
+		if err != nil {
+			continue
+		}
+
+		flights[date.Year()]++
+		times[date.Year()] += record.time.total.time
+		if times[date.Year()] > maxTime {
+			maxTime = times[date.Year()]
+		}
+	}
+
+	for year, d := range times {
+		total := logbookTime{time: d}
+		bar := yearView{Year: year, Total: total.GetTime(true), Flights: flights[year]}
+		if maxTime > 0 {
+			bar.Width = 100 * float64(d) / float64(maxTime)
+		}
+		view.Years = append(view.Years, bar)
+	}
+	sort.Slice(view.Years, func(i, j int) bool { return view.Years[i].Year < view.Years[j].Year })
+
+	var buf bytes.Buffer
+	printClassTotals(&buf, calculateClassTotals(rows, registry))
+	view.Sections = append(view.Sections, statsSection{Title: "By aircraft class", Text: buf.String()})
+
+	buf.Reset()
+	printDistanceStats(&buf, calculateDistanceStats(rows, s.airports), topRoutes)
+	view.Sections = append(view.Sections, statsSection{Title: "Distance", Text: buf.String()})
+
+	if countries := collectPlaceTotals(rows, s.airports, false); len(countries) > 0 {
+		buf.Reset()
+		printPlaceTotals(&buf, countries, false)
+		view.Sections = append(view.Sections, statsSection{Title: "Countries", Text: buf.String()})
+	}
+
+	s.render(w, "stats", view)
+}
+
+// generated returns the cached pdf or map, the content is generated in case it's not cached
+// since the last reload. The content is generated without the lock, so the other pages are
+// not blocked by the slow pdf export or the tiles download, and cached only in case the
+// logbook is not reloaded meanwhile
+func (s *logbookServer) generated(cache *[]byte, generate func(rows [][]interface{}) ([]byte, error)) ([]byte, error) {
+	s.refresh()
+
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return nil, err
+	}
+	if *cache != nil {
+		data := *cache
+		s.mu.Unlock()
+		return data, nil
+	}
+	rows, version := s.rows, s.version
+	s.mu.Unlock()
+
+	data, err := generate(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.version == version {
+		*cache = data
+	}
+	s.mu.Unlock()
+
+	return data, nil
+}
+
+// handlePDF returns the logbook in EASA format as the pdf download
+func (s *logbookServer) handlePDF(w http.ResponseWriter, r *http.Request) {
+	data, err := s.generated(&s.pdf, func(rows [][]interface{}) ([]byte, error) {
+		pdf, err := exportPDF(s.config, rows)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := pdf.Output(&buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Cannot export pdf: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="logbook.pdf"`)
+	w.Write(data)
+}
+
+// handleMap returns the rendered map of all flights
+func (s *logbookServer) handleMap(w http.ResponseWriter, r *http.Request) {
+	data, err := s.generated(&s.mapImage, func(rows [][]interface{}) ([]byte, error) {
+		scene, err := buildMapScene(s.config, rows, s.airports)
+		if err != nil {
+			return nil, err
+		}
+
+		img, err := renderMapImage(scene, s.config, true)
+		if err != nil {
+			return nil, err
+		}
+		return encodePNG(img)
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Cannot render map: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(data)
+}
+
+// handleVersion returns the version of the loaded logbook, the pages are reloaded by the
+// browser in case it's changed
+func (s *logbookServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	s.refresh()
+
+	s.mu.Lock()
+	version := s.version
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, version)
+}
+
+// Serve starts the web server with the logbook
+func Serve(logbookConfig LogbookConfig) {
+	server, err := newLogbookServer(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot start server: %v", err)
+	}
+
+	fmt.Printf("Logbook is available on http://%s\n", logbookConfig.ServeAddress)
+	if len(logbookConfig.APITokens) == 0 {
+		fmt.Println("Warning: there are no api_tokens in the config, the pages and the API are open to everyone who can reach the server")
+	} else {
+		fmt.Printf("Open http://%s/?token=TOKEN with one of the api_tokens in the browser\n", logbookConfig.ServeAddress)
+	}
+	if err := http.ListenAndServe(logbookConfig.ServeAddress, server.handler()); err != nil {
+		log.Fatalf("Cannot start server: %v", err)
+	}
+}
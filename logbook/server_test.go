@@ -0,0 +1,213 @@
+package logbook
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+var testServerCSV = `08/10/2021,LEMG,1930,LKPR,2305,B738,OK-TVS,,03:35,03:35,03:35,,1,03:35
+09/10/2021,LKPR,0600,LEMG,0930,B738,OK-TVS,,03:30,03:30,03:30,1
+11/10/2021,LKPR,0800,LKPD,0900,C152,OK-LEA,01:00,,,01:00,1
+`
+
+// serverGet returns the response of the test server
+func serverGet(t *testing.T, handler http.Handler, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	return w
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "logbook.csv")
+	assert.Equal(t, os.WriteFile(csvFile, []byte(testServerCSV), 0644), nil)
+
+	customAirports := filepath.Join(dir, "custom.csv")
+	os.WriteFile(customAirports, []byte("code,name,lat,lon\nLEMG,Malaga,36.6749,-4.49911\nLKPR,Prague,50.1008,14.26\nLKPD,Pardubice,50.0134,15.7386\n"), 0644)
+
+	server, err := newLogbookServer(LogbookConfig{
+		SourceType:     "csv",
+		FileName:       csvFile,
+		StartRow:       1,
+		AirportsDB:     filepath.Join(dir, "airports.json"),
+		CustomAirports: customAirports,
+		MBTilesFile:    emptyMBTiles(t),
+		MapWidth:       400,
+		MapHeight:      300,
+	})
+	assert.Equal(t, err, nil)
+	handler := server.handler()
+
+	w := serverGet(t, handler, "/")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.Contains(w.Body.String(), "<b>8:05</b>Total time"), true)
+	assert.Equal(t, strings.Contains(w.Body.String(), `src="map.png?v=1"`), true)
+
+	assert.Equal(t, serverGet(t, handler, "/missing").Code, http.StatusNotFound)
+
+	// the most recent flights are on the first page
+	flightsPerPage = 2
+	defer func() { flightsPerPage = 50 }()
+
+	w = serverGet(t, handler, "/flights")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.Contains(w.Body.String(), "<td>11/10/2021</td>"), true)
+	assert.Equal(t, strings.Contains(w.Body.String(), "<td>08/10/2021</td>"), false)
+	assert.Equal(t, strings.Contains(w.Body.String(), "Page 1 of 2"), true)
+
+	w = serverGet(t, handler, "/flights?page=2")
+	assert.Equal(t, strings.Contains(w.Body.String(), "<td>08/10/2021</td>"), true)
+	assert.Equal(t, serverGet(t, handler, "/flights?page=3").Code, http.StatusBadRequest)
+
+	w = serverGet(t, handler, "/stats")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, strings.Contains(w.Body.String(), "<td>2021</td>"), true)
+	assert.Equal(t, strings.Contains(w.Body.String(), "By aircraft class"), true)
+
+	w = serverGet(t, handler, "/logbook.pdf")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/pdf")
+	assert.Equal(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")), true)
+
+	w = serverGet(t, handler, "/map.png")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Content-Type"), "image/png")
+
+	// the logbook is reloaded after the file is changed
+	assert.Equal(t, serverGet(t, handler, "/version").Body.String(), "1")
+
+	os.WriteFile(csvFile, []byte(testServerCSV+"12/10/2021,LKPD,0800,LKPR,0900,C152,OK-LEA,01:00,,,01:00,1\n"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(csvFile, later, later)
+
+	assert.Equal(t, serverGet(t, handler, "/version").Body.String(), "2")
+	w = serverGet(t, handler, "/")
+	assert.Equal(t, strings.Contains(w.Body.String(), "<b>9:05</b>Total time"), true)
+
+	// the last loaded records are shown with the error
+	os.Remove(csvFile)
+
+	w = serverGet(t, handler, "/")
+	assert.Equal(t, strings.Contains(w.Body.String(), "Cannot load logbook"), true)
+	assert.Equal(t, strings.Contains(w.Body.String(), "<b>9:05</b>Total time"), true)
+	assert.Equal(t, serverGet(t, handler, "/logbook.pdf").Code, http.StatusInternalServerError)
+}
+
+func TestServerGenerated(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "logbook.csv")
+	assert.Equal(t, os.WriteFile(csvFile, []byte(testServerCSV), 0644), nil)
+
+	server, err := newLogbookServer(LogbookConfig{
+		SourceType: "csv",
+		FileName:   csvFile,
+		StartRow:   1,
+		AirportsDB: filepath.Join(dir, "airports.json"),
+	})
+	assert.Equal(t, err, nil)
+	handler := server.handler()
+
+	// the other pages are not blocked while the content is generated
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan []byte)
+
+	var cache []byte
+	go func() {
+		data, _ := server.generated(&cache, func(rows [][]interface{}) ([]byte, error) {
+			close(started)
+			<-release
+			return []byte("content"), nil
+		})
+		done <- data
+	}()
+
+	<-started
+	assert.Equal(t, serverGet(t, handler, "/version").Body.String(), "1")
+
+	// the logbook is reloaded meanwhile, the stale content is returned but not cached
+	os.WriteFile(csvFile, []byte(testServerCSV+"12/10/2021,LKPD,0800,LKPR,0900,C152,OK-LEA,01:00,,,01:00,1\n"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(csvFile, later, later)
+	assert.Equal(t, serverGet(t, handler, "/version").Body.String(), "2")
+
+	close(release)
+	assert.Equal(t, string(<-done), "content")
+	assert.Equal(t, cache == nil, true)
+
+	data, err := server.generated(&cache, func(rows [][]interface{}) ([]byte, error) {
+		return []byte("reloaded"), nil
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(data), "reloaded")
+	assert.Equal(t, string(cache), "reloaded")
+}
+
+func TestServerSlowSource(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "logbook.csv")
+	assert.Equal(t, os.WriteFile(csvFile, []byte(testServerCSV), 0644), nil)
+
+	// the google spreadsheet is stalled after the first load
+	requests := 0
+	stalled := make(chan struct{})
+	release := make(chan struct{})
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			close(stalled)
+			<-release
+		}
+		fmt.Fprint(w, `{"values": [["06/03/2020", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "ULT-28", "01:30"]]}`)
+	}))
+	defer google.Close()
+
+	server, err := newLogbookServer(LogbookConfig{
+		StartRow:       1,
+		AirportsDB:     filepath.Join(dir, "airports.json"),
+		APIKey:         "test-key",
+		CacheDir:       dir,
+		GoogleEndpoint: google.URL + "/",
+		Sources: []SourceConfig{
+			{SourceType: "csv", FileName: csvFile},
+			{SourceType: "google", SpreadsheetID: "test-id"},
+		},
+	})
+	assert.Equal(t, err, nil)
+	handler := server.handler()
+
+	os.WriteFile(csvFile, []byte(testServerCSV+"12/10/2021,LKPD,0800,LKPR,0900,C152,OK-LEA,01:00,,,01:00,1\n"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(csvFile, later, later)
+
+	reloaded := make(chan struct{})
+	go func() {
+		server.refresh()
+		close(reloaded)
+	}()
+	<-stalled
+
+	// the previous records are shown during the reload
+	assert.Equal(t, serverGet(t, handler, "/version").Body.String(), "1")
+	w := serverGet(t, handler, "/")
+	assert.Equal(t, strings.Contains(w.Body.String(), "<b>8:05</b>Total time"), true)
+
+	close(release)
+	<-reloaded
+
+	assert.Equal(t, serverGet(t, handler, "/version").Body.String(), "2")
+	w = serverGet(t, handler, "/")
+	assert.Equal(t, strings.Contains(w.Body.String(), "<b>9:05</b>Total time"), true)
+}
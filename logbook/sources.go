@@ -0,0 +1,161 @@
+package logbook
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SourceConfig is one of the logbook sources in case the records are kept in several places
+type SourceConfig struct {
+	SourceType    string
+	FileName      string
+	SpreadsheetID string
+	SheetName     string
+	StartRow      int
+}
+
+// sourceConfigs returns the logbook configs for every source, the common
+// parameters (authentication, cache and so on) are taken from the main config
+func sourceConfigs(logbookConfig LogbookConfig) []LogbookConfig {
+	var configs []LogbookConfig
+
+	for _, source := range logbookConfig.Sources {
+		config := logbookConfig
+		config.Sources = nil
+		config.SourceType = source.SourceType
+		config.FileName = source.FileName
+		config.SpreadsheetID = source.SpreadsheetID
+		if source.SheetName != "" {
+			config.SheetName = source.SheetName
+		}
+		if source.StartRow != 0 {
+			config.StartRow = source.StartRow
+		}
+		configs = append(configs, config)
+	}
+
+	return configs
+}
+
+// getCSVDump reads the logbook from the csv file with the same columns as the "Flights" sheet
+func getCSVDump(logbookConfig LogbookConfig) (values [][]interface{}, err error) {
+	file, err := os.Open(logbookConfig.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("error opening csv file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read csv file: %v", err)
+	}
+
+	for i, row := range rows {
+		// skip first rows (headers)
+		if i < logbookConfig.StartRow-1 {
+			continue
+		}
+
+		var appendRow []interface{}
+		for _, colCell := range row {
+			appendRow = append(appendRow, strings.TrimSpace(colCell))
+		}
+		values = append(values, appendRow)
+	}
+
+	return values, nil
+}
+
+// mergedRow is a logbook row with the fields to sort and deduplicate the merged records
+type mergedRow struct {
+	row  []interface{}
+	date time.Time
+	time string
+	key  string
+}
+
+// getMergedDump reads all sources and merges them into one logbook. The records are sorted
+// by date and departure time and the duplicates are removed. The order is the same as
+// for one source, so the most recent records are on the top for the reverse logbook
+func getMergedDump(logbookConfig LogbookConfig) (values [][]interface{}, err error) {
+	var rows []mergedRow
+	merged := make(map[string]int)
+	duplicates := 0
+
+	for _, config := range sourceConfigs(logbookConfig) {
+		sourceValues, err := getSourceDump(config)
+		if err != nil {
+			return nil, fmt.Errorf("source %s %s%s: %v", config.SourceType, config.FileName, config.SpreadsheetID, err)
+		}
+
+		wrongDates := 0
+		counts := make(map[string]int)
+		for _, row := range sourceValues {
+			record := parseRecord(row)
+
+			// the key is formed from the parsed record, so 03:35 and 3:35 are the same
+			key := strings.Join(formatRecord(record), "\t")
+			if strings.TrimSpace(key) == "" {
+				continue
+			}
+			// the same records can be in one source (e.g. several identical flights per day),
+			// so the record is a duplicate only in case it's already merged as many times
+			// from the previous sources
+			counts[key]++
+			if counts[key] <= merged[key] {
+				duplicates++
+				continue
+			}
+
+			date, err := time.Parse(dateLayout, record.date)
+			if err != nil {
+				wrongDates++
+			}
+
+			rows = append(rows, mergedRow{row: row, date: date, time: record.departure.time, key: key})
+		}
+
+		for key, count := range counts {
+			if count > merged[key] {
+				merged[key] = count
+			}
+		}
+
+		if wrongDates > 0 {
+			log.Printf("Warning: %d records with wrong date in the source %s %s%s\n", wrongDates, config.SourceType, config.FileName, config.SpreadsheetID)
+		}
+	}
+
+	if duplicates > 0 {
+		log.Printf("%d duplicated records have been skipped\n", duplicates)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if !rows[i].date.Equal(rows[j].date) {
+			return rows[i].date.Before(rows[j].date)
+		}
+		return rows[i].time < rows[j].time
+	})
+
+	for i := range rows {
+		if logbookConfig.Reverse {
+			values = append(values, rows[len(rows)-1-i].row)
+		} else {
+			values = append(values, rows[i].row)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no data found in the sources")
+	}
+
+	return values, nil
+}
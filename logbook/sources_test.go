@@ -0,0 +1,117 @@
+package logbook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMergedDump(t *testing.T) {
+	dir := t.TempDir()
+
+	var record logbookRecord
+	record.date = "01/10/2021"
+	record.departure.place = "LKSZ"
+	record.departure.time = "1000"
+	record.arrival.place = "LKPR"
+	record.time.total.SetTime("1:00")
+
+	xlsxFile := filepath.Join(dir, "operator1.xlsx")
+	err := saveImportXLSX(xlsxFile, []logbookRecord{record})
+	assert.Equal(t, err, nil)
+
+	// the same flight in other format, another flight on the same date and the older one
+	csvFile := filepath.Join(dir, "operator2.csv")
+	os.WriteFile(csvFile, []byte(`Date,From,Time,To
+01/10/2021,LKPR,1200,LKSZ,,,,,,,01:00
+01/10/2021,LKSZ,1000,LKPR,,,,,,,01:00
+01/10/2021,LKPR,1200,LKSZ,,,,,,,01:00
+15/09/2021,LKTB,0800,LKTB,,,,,,,00:30
+`), 0644)
+
+	logbookConfig := LogbookConfig{
+		StartRow: importStartRow,
+		Reverse:  true,
+		Sources: []SourceConfig{
+			{SourceType: "xlsx", FileName: xlsxFile},
+			{SourceType: "csv", FileName: csvFile, StartRow: 2},
+		},
+	}
+
+	values, err := getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 4)
+	assert.Equal(t, parseRecord(values[0]).departure.time, "1200")
+	assert.Equal(t, parseRecord(values[1]).departure.time, "1200")
+	assert.Equal(t, parseRecord(values[2]).departure.time, "1000")
+	assert.Equal(t, parseRecord(values[3]).date, "15/09/2021")
+
+	logbookConfig.Reverse = false
+	values, _ = getLogbookDump(logbookConfig)
+	assert.Equal(t, parseRecord(values[0]).departure.place, "LKTB")
+
+	// the flight is twice in one source and once in the others, e.g. two identical legs
+	// on the same day, both copies are kept
+	csvFile = filepath.Join(dir, "operator3.csv")
+	os.WriteFile(csvFile, []byte(`01/10/2021,LKSZ,1000,LKPR,,,,,,,01:00
+01/10/2021,LKSZ,1000,LKPR,,,,,,,1:00
+`), 0644)
+
+	logbookConfig.Sources = append(logbookConfig.Sources, SourceConfig{SourceType: "csv", FileName: csvFile, StartRow: 1})
+	values, err = getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 5)
+
+	legs := 0
+	for _, value := range values {
+		if parseRecord(value).departure.time == "1000" {
+			legs++
+		}
+	}
+	assert.Equal(t, legs, 2)
+
+	logbookConfig.Sources = append(logbookConfig.Sources, SourceConfig{SourceType: "csv", FileName: filepath.Join(dir, "none.csv")})
+	_, err = getLogbookDump(logbookConfig)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestMergedDumpSheetName(t *testing.T) {
+	dir := t.TempDir()
+
+	var record logbookRecord
+	record.date = "01/10/2021"
+	record.departure.place = "LKSZ"
+	record.departure.time = "1000"
+	record.arrival.place = "LKPR"
+	record.time.total.SetTime("1:00")
+
+	// the global sheet name is used in case the source doesn't have its own one
+	xlsxFile := filepath.Join(dir, "operator1.xlsx")
+	assert.Equal(t, saveImportXLSX(xlsxFile, []logbookRecord{record}), nil)
+
+	xls, err := excelize.OpenFile(xlsxFile)
+	assert.Equal(t, err, nil)
+	xls.SetSheetName(sheetName, "My Flights")
+	assert.Equal(t, xls.Save(), nil)
+
+	logbookConfig := LogbookConfig{
+		StartRow:  importStartRow,
+		SheetName: "My Flights",
+		Sources:   []SourceConfig{{SourceType: "xlsx", FileName: xlsxFile}},
+	}
+
+	configs := sourceConfigs(logbookConfig)
+	assert.Equal(t, configs[0].SheetName, "My Flights")
+
+	values, err := getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 1)
+
+	// the sheet name of the source replaces the global one
+	logbookConfig.Sources[0].SheetName = "Other"
+	configs = sourceConfigs(logbookConfig)
+	assert.Equal(t, configs[0].SheetName, "Other")
+}
@@ -0,0 +1,90 @@
+package logbook
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+// number of the most flown routes in the stats
+var topRoutes = 10
+
+// printClassTotals prints the table with the totals by the aircraft class
+func printClassTotals(w io.Writer, classes []classTotals) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Class\tFlights\tTotal\tSE\tME\tMCC\tLandings")
+	for _, class := range classes {
+		totals := class.totals
+		time := totals.time.total
+		if class.class == "Simulator" {
+			time = totals.sim.time
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%d\n",
+			class.class, class.flights, time.GetTime(true),
+			totals.time.se.GetTime(true), totals.time.me.GetTime(true), totals.time.mcc.GetTime(true),
+			totals.landings.day+totals.landings.night,
+		)
+	}
+
+	tw.Flush()
+}
+
+// Stats prints the logbook statistics
+func Stats(logbookConfig LogbookConfig) {
+	registry, err := loadAircraftRegistry(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot load aircraft registry: %v", err)
+	}
+
+	airports, err := loadAirportsDB(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot load airports database: %v", err)
+	}
+
+	response, err := getLogbookDump(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot get logbook dump: %v", err)
+	}
+
+	var totals logbookTotalRecord
+	for _, row := range response {
+		totals = calculateTotals(totals, parseRecord(row))
+	}
+
+	fmt.Printf("Total time: %s\n", totals.time.total.GetTime(true))
+	fmt.Printf("Simulator time: %s\n", totals.sim.time.GetTime(true))
+	fmt.Printf("Landings: %d day, %d night\n", totals.landings.day, totals.landings.night)
+
+	fmt.Println("\nBy aircraft class")
+	printClassTotals(os.Stdout, calculateClassTotals(response, registry))
+
+	distances := calculateDistanceStats(response, airports)
+
+	fmt.Println("\nDistance")
+	printDistanceStats(os.Stdout, distances, topRoutes)
+
+	countries := collectPlaceTotals(response, airports, false)
+	regions := 0
+	for _, country := range countries {
+		regions += country.regions
+	}
+
+	fmt.Printf("\nCountries: %d, regions: %d\n", len(countries), regions)
+	if len(countries) > 0 {
+		printPlaceTotals(os.Stdout, countries, false)
+	}
+
+	if logbookConfig.ShowRegions && len(countries) > 0 {
+		fmt.Println("\nRegions")
+		printPlaceTotals(os.Stdout, collectPlaceTotals(response, airports, true), true)
+	}
+
+	if logbookConfig.ShowDistances {
+		fmt.Println("\nFlights")
+		printFlightDistances(os.Stdout, distances)
+	}
+}
@@ -0,0 +1,171 @@
+package logbook
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// airportVisits is the number of departures and arrivals at the airport
+type airportVisits struct {
+	code       string
+	airport    Airport
+	departures int
+	arrivals   int
+	firstVisit time.Time
+	lastVisit  time.Time
+}
+
+// visits returns the number of departures and arrivals together
+func (v airportVisits) visits() int {
+	return v.departures + v.arrivals
+}
+
+// routeTotals is the number of flights and the total time on the route, the flights in
+// both directions are the same route
+type routeTotals struct {
+	departure Airport
+	arrival   Airport
+	flights   int
+	time      time.Duration
+	distance  float64
+}
+
+// filterRowsByDate returns the rows with the date containing the filter, all rows in case
+// the filter is empty
+func filterRowsByDate(rows [][]interface{}, filterDate string) [][]interface{} {
+	if filterDate == "" {
+		return rows
+	}
+
+	var filteredRows [][]interface{}
+	for _, row := range rows {
+		if strings.Contains(parseRecord(row).date, filterDate) {
+			filteredRows = append(filteredRows, row)
+		}
+	}
+
+	return filteredRows
+}
+
+// collectAirportVisits returns the visits of the airports sorted by the code and the list of
+// the codes not found in the airports database
+//
+// rows [][]interface{} - logbook rows
+//
+// airports airportsDB - airports database
+func collectAirportVisits(rows [][]interface{}, airports airportsDB) ([]airportVisits, []string) {
+	visits := make(map[string]*airportVisits)
+	missing := make(map[string]struct{})
+
+	visit := func(code string, date time.Time, departure bool) {
+		if code == "" {
+			return
+		}
+
+		airport, ok := airports.lookup(code)
+		if !ok {
+			missing[code] = struct{}{}
+			return
+		}
+
+		v, ok := visits[airport.ICAO]
+		if !ok {
+			v = &airportVisits{code: code, airport: airport}
+			visits[airport.ICAO] = v
+		}
+
+		if departure {
+			v.departures++
+		} else {
+			v.arrivals++
+		}
+
+		if !date.IsZero() {
+			if v.firstVisit.IsZero() || date.Before(v.firstVisit) {
+				v.firstVisit = date
+			}
+			if date.After(v.lastVisit) {
+				v.lastVisit = date
+			}
+		}
+	}
+
+	for _, row := range rows {
+		record := parseRecord(row)
+
+		// the date is optional here, the visits are counted anyway
+		date, _ := time.Parse(dateLayout, record.date)
+
+		visit(record.departure.place, date, true)
+		visit(record.arrival.place, date, false)
+	}
+
+	var result []airportVisits
+	for _, v := range visits {
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].code < result[j].code })
+
+	var missingCodes []string
+	for code := range missing {
+		missingCodes = append(missingCodes, code)
+	}
+	sort.Strings(missingCodes)
+
+	return result, missingCodes
+}
+
+// collectRouteTotals returns the routes between the airports sorted by the number of flights,
+// the local flights and the airports not found in the database are skipped
+//
+// rows [][]interface{} - logbook rows
+//
+// airports airportsDB - airports database
+func collectRouteTotals(rows [][]interface{}, airports airportsDB) []routeTotals {
+	routes := make(map[string]*routeTotals)
+
+	for _, row := range rows {
+		record := parseRecord(row)
+
+		departure, ok := airports.lookup(record.departure.place)
+		if !ok {
+			continue
+		}
+		arrival, ok := airports.lookup(record.arrival.place)
+		if !ok || departure.ICAO == arrival.ICAO {
+			continue
+		}
+
+		if arrival.ICAO < departure.ICAO {
+			departure, arrival = arrival, departure
+		}
+
+		key := routeKey(departure.ICAO, arrival.ICAO)
+		route, ok := routes[key]
+		if !ok {
+			route = &routeTotals{
+				departure: departure,
+				arrival:   arrival,
+				distance:  departure.latLng().Distance(arrival.latLng()).Radians() * earthRadius,
+			}
+			routes[key] = route
+		}
+
+		route.flights++
+		route.time += record.time.total.time
+	}
+
+	var result []routeTotals
+	for _, route := range routes {
+		result = append(result, *route)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].flights != result[j].flights {
+			return result[i].flights > result[j].flights
+		}
+		return routeKey(result[i].departure.ICAO, result[i].arrival.ICAO) < routeKey(result[j].departure.ICAO, result[j].arrival.ICAO)
+	})
+
+	return result
+}
@@ -0,0 +1,271 @@
+package logbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// statsCountry is the visited country in the stats file
+type statsCountry struct {
+	Country    string `json:"country"`
+	Regions    int    `json:"regions"`
+	Airports   int    `json:"airports"`
+	Flights    int    `json:"flights"`
+	Time       int    `json:"time"`
+	Landings   int    `json:"landings"`
+	FirstVisit string `json:"first_visit,omitempty"`
+}
+
+// statsReport is the content of the stats file, the times are in minutes
+type statsReport struct {
+	Generated string         `json:"generated"`
+	Totals    apiTotals      `json:"totals"`
+	Years     []apiTotals    `json:"years"`
+	Countries []statsCountry `json:"countries"`
+	Currency  []apiCurrency  `json:"currency"`
+}
+
+// newStatsReport returns the totals, the totals per year, the visited countries and the
+// currency status of the logbook
+func newStatsReport(rows [][]interface{}, airports airportsDB, now time.Time) statsReport {
+	records := filterRecords(rows, apiRecordFilter{})
+
+	report := statsReport{
+		Generated: now.Format(time.RFC3339),
+		Totals:    apiTotals{Period: "all"},
+		Years:     calculatePeriodTotals(records, "2006"),
+		Countries: []statsCountry{},
+		Currency:  newAPICurrency(calculateCurrency(rows, now)),
+	}
+
+	if totals := calculatePeriodTotals(records, ""); len(totals) > 0 {
+		report.Totals = totals[0]
+	}
+
+	for _, place := range collectPlaceTotals(rows, airports, false) {
+		country := statsCountry{
+			Country:  place.country,
+			Regions:  place.regions,
+			Airports: place.airports,
+			Flights:  place.flights,
+			Time:     minutes(logbookTime{time: place.time}),
+			Landings: place.landings,
+		}
+		if !place.firstVisit.IsZero() {
+			country.FirstVisit = place.firstVisit.Format(apiDateLayout)
+		}
+
+		report.Countries = append(report.Countries, country)
+	}
+
+	return report
+}
+
+// checkRowFormats validates the format of the logbook rows the same way as the new records,
+// the empty rows are skipped
+func checkRowFormats(logbookConfig LogbookConfig, rows [][]interface{}) []checkIssue {
+	var issues []checkIssue
+
+	withRows := len(logbookConfig.Sources) == 0 && logbookConfig.SourceType != "db"
+
+	for i, row := range rows {
+		cells := make([]string, len(header3))
+		empty := true
+		for j := 0; j < len(row) && j < len(cells); j++ {
+			cells[j], _ = row[j].(string)
+			if cells[j] != "" {
+				empty = false
+			}
+		}
+
+		if empty {
+			continue
+		}
+
+		if err := validateRow(cells); err != nil {
+			issue := checkIssue{record: parseRecord(row), message: err.Error()}
+			if withRows {
+				issue.row = logbookConfig.StartRow + i
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// generateOutputs reads the logbook and saves the configured pdf, map and stats files. The
+// wrong rows are reported and the outputs are still generated, the outputs are not changed
+// in case the logbook cannot be read
+//
+// logbookConfig LogbookConfig - logbook configuration with the output files
+//
+// airports airportsDB - airports database
+func generateOutputs(logbookConfig LogbookConfig, airports airportsDB) error {
+	rows, err := getLogbookDump(logbookConfig)
+	if err != nil {
+		return fmt.Errorf("cannot get logbook dump: %v", err)
+	}
+
+	for _, issue := range checkRowFormats(logbookConfig, rows) {
+		fmt.Println(issue)
+	}
+
+	var errs []string
+
+	if logbookConfig.WatchPDF != "" {
+		pdf, err := exportPDF(logbookConfig, rows)
+		if err == nil {
+			err = pdf.OutputFileAndClose(logbookConfig.WatchPDF)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot export pdf: %v", err))
+		} else {
+			fmt.Printf("Logbook has been exported to %s\n", logbookConfig.WatchPDF)
+		}
+	}
+
+	if logbookConfig.WatchMap != "" {
+		scene, err := buildMapScene(logbookConfig, rows, airports)
+		if err == nil {
+			err = saveMap(logbookConfig.WatchMap, scene, logbookConfig)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot save a map: %v", err))
+		} else {
+			fmt.Printf("Map has been saved to %s\n", logbookConfig.WatchMap)
+		}
+	}
+
+	if logbookConfig.WatchStats != "" {
+		data, err := json.MarshalIndent(newStatsReport(rows, airports, time.Now()), "", "  ")
+		if err == nil {
+			err = os.WriteFile(logbookConfig.WatchStats, data, 0644)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot save stats: %v", err))
+		} else {
+			fmt.Printf("Stats have been saved to %s\n", logbookConfig.WatchStats)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// watchLogbook generates the outputs and regenerates them every time the logbook files are
+// changed, the changes are collected during the watch delay, so the outputs are generated
+// once after the file is saved. The errors are reported and the watching continues until
+// done is closed
+//
+// logbookConfig LogbookConfig - logbook configuration with the output files
+//
+// airports airportsDB - airports database
+//
+// done <-chan struct{} - stops the watching, can be nil
+func watchLogbook(logbookConfig LogbookConfig, airports airportsDB, done <-chan struct{}) error {
+	if logbookConfig.WatchPDF == "" && logbookConfig.WatchMap == "" && logbookConfig.WatchStats == "" {
+		return fmt.Errorf("there are no outputs to generate")
+	}
+
+	files := sourceFiles(logbookConfig)
+	if len(files) == 0 {
+		return fmt.Errorf("there are no local logbook files, the google spreadsheets cannot be watched")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// the directories are watched, the editors often replace the file while saving
+	watched := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		watched[file] = true
+
+		if dir := filepath.Dir(file); !dirs[dir] {
+			if err := watcher.Add(dir); err != nil {
+				return fmt.Errorf("cannot watch %s: %v", dir, err)
+			}
+			dirs[dir] = true
+		}
+	}
+
+	var fingerprint string
+	generate := func() {
+		fingerprint = sourceFingerprint(files)
+		if err := generateOutputs(logbookConfig, airports); err != nil {
+			log.Printf("Cannot update outputs: %v", err)
+		}
+	}
+
+	generate()
+	fmt.Printf("Watching %s for changes\n", strings.Join(files, ", "))
+
+	delay := time.Duration(logbookConfig.WatchDelay) * time.Millisecond
+	var changed <-chan time.Time
+
+	for {
+		select {
+		case <-done:
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if file, err := filepath.Abs(event.Name); err == nil && watched[file] {
+				changed = time.After(delay)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Watch error: %v", err)
+
+		case <-changed:
+			changed = nil
+
+			// e.g. only the file permissions are changed
+			if sourceFingerprint(files) == fingerprint {
+				continue
+			}
+
+			fmt.Printf("\n%s: logbook has been changed\n", time.Now().Format("15:04:05"))
+			generate()
+		}
+	}
+}
+
+// Watch generates the pdf, map and stats files and regenerates them after the logbook file
+// is changed
+func Watch(logbookConfig LogbookConfig) {
+	airports, err := loadAirportsDB(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot load airports database: %v", err)
+	}
+
+	if err := watchLogbook(logbookConfig, airports, nil); err != nil {
+		log.Fatalf("Cannot watch logbook: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package logbook
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// testWatchConfig returns the config with the csv logbook and the outputs in the temp directory
+func testWatchConfig(t *testing.T) (LogbookConfig, airportsDB) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "logbook.csv")
+	assert.Equal(t, os.WriteFile(csvFile, []byte(testServerCSV), 0644), nil)
+
+	customAirports := filepath.Join(dir, "custom.csv")
+	os.WriteFile(customAirports, []byte("code,name,lat,lon\nLEMG,Malaga,36.6749,-4.49911\nLKPR,Prague,50.1008,14.26\nLKPD,Pardubice,50.0134,15.7386\n"), 0644)
+
+	logbookConfig := LogbookConfig{
+		SourceType:     "csv",
+		FileName:       csvFile,
+		StartRow:       1,
+		AirportsDB:     filepath.Join(dir, "airports.json"),
+		CustomAirports: customAirports,
+		MBTilesFile:    emptyMBTiles(t),
+		MapWidth:       400,
+		MapHeight:      300,
+		WatchStats:     filepath.Join(dir, "stats.json"),
+		WatchDelay:     50,
+	}
+
+	airports, err := loadAirportsDB(logbookConfig)
+	assert.Equal(t, err, nil)
+
+	return logbookConfig, airports
+}
+
+// readStats returns the saved stats file
+func readStats(t *testing.T, fileName string) statsReport {
+	var report statsReport
+
+	data, err := os.ReadFile(fileName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, json.Unmarshal(data, &report), nil)
+
+	return report
+}
+
+func TestGenerateOutputs(t *testing.T) {
+	logbookConfig, airports := testWatchConfig(t)
+
+	dir := filepath.Dir(logbookConfig.FileName)
+	logbookConfig.WatchPDF = filepath.Join(dir, "logbook.pdf")
+	logbookConfig.WatchMap = filepath.Join(dir, "map.png")
+
+	assert.Equal(t, generateOutputs(logbookConfig, airports), nil)
+
+	for _, file := range []string{logbookConfig.WatchPDF, logbookConfig.WatchMap} {
+		info, err := os.Stat(file)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, info.Size() > 0, true)
+	}
+
+	report := readStats(t, logbookConfig.WatchStats)
+	assert.Equal(t, report.Totals.Flights, 3)
+	assert.Equal(t, report.Totals.Time.Total, 485)
+	assert.Equal(t, len(report.Years), 1)
+	assert.Equal(t, report.Years[0].Period, "2021")
+	assert.Equal(t, len(report.Currency), 4)
+
+	// the wrong rows are reported, the outputs are still generated
+	os.WriteFile(logbookConfig.FileName, []byte(testServerCSV+"12/10/2021,LKPD,8:00,LKPR,0900,C152,OK-LEA,01:00,,,01:00,1\n"), 0644)
+	issues := checkRowFormats(logbookConfig, [][]interface{}{{"12/10/2021", "LKPD", "8:00", "LKPR", "0900", "C152", "OK-LEA", "01:00", "", "", "01:00", "1"}, {}})
+	assert.Equal(t, len(issues), 1)
+	assert.Equal(t, issues[0].String(), "row 1, 12/10/2021 LKPD-LKPR: wrong time 8:00, should be HHMM")
+
+	assert.Equal(t, generateOutputs(logbookConfig, airports), nil)
+	assert.Equal(t, readStats(t, logbookConfig.WatchStats).Totals.Flights, 4)
+
+	// the outputs are not changed in case the logbook cannot be read
+	os.WriteFile(logbookConfig.FileName, []byte("\"08/10/2021,LEMG\"x\n"), 0644)
+
+	err := generateOutputs(logbookConfig, airports)
+	assert.Equal(t, err != nil, true)
+	assert.Equal(t, readStats(t, logbookConfig.WatchStats).Totals.Flights, 4)
+}
+
+func TestWatchLogbook(t *testing.T) {
+	logbookConfig, airports := testWatchConfig(t)
+
+	done := make(chan struct{})
+	stopped := make(chan error)
+	go func() {
+		stopped <- watchLogbook(logbookConfig, airports, done)
+	}()
+
+	// waitFlights waits until the stats file has the number of flights
+	waitFlights := func(flights int) bool {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+			data, err := os.ReadFile(logbookConfig.WatchStats)
+			var report statsReport
+			if err == nil && json.Unmarshal(data, &report) == nil && report.Totals.Flights == flights {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.Equal(t, waitFlights(3), true)
+
+	// the broken file is reported and the watching continues
+	os.WriteFile(logbookConfig.FileName, []byte("\"broken"), 0644)
+	time.Sleep(200 * time.Millisecond)
+
+	os.WriteFile(logbookConfig.FileName, []byte(testServerCSV+"12/10/2021,LKPD,0800,LKPR,0900,C152,OK-LEA,01:00,,,01:00,1\n"), 0644)
+	assert.Equal(t, waitFlights(4), true)
+
+	close(done)
+	assert.Equal(t, <-stopped, nil)
+
+	// there is nothing to generate
+	logbookConfig.WatchStats = ""
+	assert.Equal(t, watchLogbook(logbookConfig, airports, done).Error(), "there are no outputs to generate")
+}
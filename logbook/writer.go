@@ -0,0 +1,306 @@
+package logbook
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// RecordFields are the names of the logbook row fields in the same order as the columns
+var RecordFields = []string{
+	"date", "departure", "departure-time", "arrival", "arrival-time", "model", "reg",
+	"se", "me", "mcc", "total", "landings-day", "landings-night", "night", "ifr",
+	"pic", "copilot", "dual", "instructor", "sim-type", "sim-time", "pic-name", "remarks",
+}
+
+// contains checks if the slice contains the value
+func contains(slice []int, value int) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// newTimeStyle creates the cell style for the time columns, the same as in the internal/logbook.xlsx
+func newTimeStyle(xls *excelize.File) (int, error) {
+	return xls.NewStyle(`{"custom_number_format":"hh:mm"}`)
+}
+
+// setXLSXRow writes the logbook row values to the sheet. Times are written as a
+// part of the day and landings as numbers, so the formulas in the sheet can use them
+//
+// xls *excelize.File - xlsx file
+//
+// sheet string - sheet name
+//
+// rowNum int - row number in the sheet
+//
+// row []string - logbook row in the 23 columns layout
+//
+// columns []int - columns to write, other cells are untouched
+//
+// timeStyle int - style for the time cells which don't have any style yet
+func setXLSXRow(xls *excelize.File, sheet string, rowNum int, row []string, columns []int, timeStyle int) error {
+	for _, col := range columns {
+		axis, err := excelize.CoordinatesToCellName(col+1, rowNum)
+		if err != nil {
+			return err
+		}
+
+		value := row[col]
+
+		if contains(timeColumns, col) {
+			style, err := xls.GetCellStyle(sheet, axis)
+			if err != nil {
+				return err
+			}
+			if style == 0 {
+				if err := xls.SetCellStyle(sheet, axis, axis, timeStyle); err != nil {
+					return err
+				}
+			}
+		}
+
+		if value == "" {
+			err = xls.SetCellValue(sheet, axis, nil)
+		} else if contains(timeColumns, col) {
+			duration, _ := parseLogbookTime(value)
+			err = xls.SetCellFloat(sheet, axis, duration.Hours()/24, -1, 64)
+		} else if contains(landingsColumns, col) {
+			num, _ := strconv.Atoi(value)
+			err = xls.SetCellInt(sheet, axis, num)
+		} else {
+			err = xls.SetCellStr(sheet, axis, value)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// allColumns returns the list of all logbook row columns
+func allColumns() []int {
+	var columns []int
+	for i := range RecordFields {
+		columns = append(columns, i)
+	}
+	return columns
+}
+
+// openXLSXSource opens the xlsx logbook and returns its rows
+func openXLSXSource(logbookConfig LogbookConfig) (*excelize.File, [][]string, error) {
+	if len(logbookConfig.Sources) > 0 {
+		return nil, nil, fmt.Errorf("the records cannot be modified when several sources are set, edit the source file directly")
+	}
+
+	if logbookConfig.SourceType != "xlsx" {
+		return nil, nil, fmt.Errorf("only xlsx source can be modified")
+	}
+
+	xls, err := excelize.OpenFile(logbookConfig.FileName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening xlsx file: %v", err)
+	}
+
+	rows, err := xls.GetRows(logbookConfig.sheet())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to retrieve data from sheet: %v", err)
+	}
+
+	return xls, rows, nil
+}
+
+// newRecordRow forms the logbook row from the record fields
+//
+// row []string - current row values, can be nil for the new record
+//
+// fields map[string]string - new values of the fields
+func newRecordRow(row []string, fields map[string]string) ([]string, []int, error) {
+	newRow := make([]string, len(RecordFields))
+	for i := range row {
+		if i < len(newRow) {
+			newRow[i] = normalizeXLSXCell(row[i])
+		}
+	}
+
+	var columns []int
+	for i, name := range RecordFields {
+		if value, ok := fields[name]; ok {
+			newRow[i] = strings.TrimSpace(value)
+			columns = append(columns, i)
+		}
+	}
+
+	for name := range fields {
+		found := false
+		for _, field := range RecordFields {
+			if name == field {
+				found = true
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("unknown field %s", name)
+		}
+	}
+
+	if err := validateRow(newRow); err != nil {
+		return nil, nil, err
+	}
+
+	return newRow, columns, nil
+}
+
+// checkRowNumber verifies the row number points to the logbook record
+func checkRowNumber(logbookConfig LogbookConfig, rows [][]string) error {
+	if logbookConfig.Row < logbookConfig.StartRow || logbookConfig.Row > len(rows) {
+		return fmt.Errorf("row %d is not a logbook record, the records are in the rows %d-%d", logbookConfig.Row, logbookConfig.StartRow, len(rows))
+	}
+	return nil
+}
+
+// isRecordRow checks the sheet row is a logbook record, not an empty, totals or signature row
+func isRecordRow(row []string) bool {
+	cells := make([]string, len(RecordFields))
+	for i := 0; i < len(row) && i < len(cells); i++ {
+		cells[i] = normalizeXLSXCell(row[i])
+	}
+	return validateRow(cells) == nil
+}
+
+// addRecord inserts the new record to the logbook sheet, it will be the first
+// record for the reverse logbook and the last one for the straight logbook.
+// The row is duplicated from the neighbour record, so it keeps the same formatting.
+// The rows after the records (e.g. totals or signature) stay below the new record
+func addRecord(logbookConfig LogbookConfig) (int, error) {
+	xls, rows, err := openXLSXSource(logbookConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	row, _, err := newRecordRow(nil, logbookConfig.Record)
+	if err != nil {
+		return 0, err
+	}
+
+	// row numbers of the first and the last records in the sheet
+	first, last := 0, 0
+	for i := logbookConfig.StartRow - 1; i < len(rows); i++ {
+		if isRecordRow(rows[i]) {
+			if first == 0 {
+				first = i + 1
+			}
+			last = i + 1
+		}
+	}
+
+	rowNum := logbookConfig.StartRow
+	if first == 0 {
+		// no records yet, the new one goes to the start row
+		if len(rows) >= rowNum {
+			err = xls.InsertRow(logbookConfig.sheet(), rowNum)
+		}
+	} else if logbookConfig.Reverse {
+		rowNum = first
+		err = xls.DuplicateRow(logbookConfig.sheet(), rowNum)
+	} else {
+		rowNum = last + 1
+		err = xls.DuplicateRow(logbookConfig.sheet(), last)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	timeStyle, err := newTimeStyle(xls)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setXLSXRow(xls, logbookConfig.sheet(), rowNum, row, allColumns(), timeStyle); err != nil {
+		return 0, err
+	}
+
+	return rowNum, xls.Save()
+}
+
+// editRecord updates the fields of the record in the row
+func editRecord(logbookConfig LogbookConfig) error {
+	xls, rows, err := openXLSXSource(logbookConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := checkRowNumber(logbookConfig, rows); err != nil {
+		return err
+	}
+
+	row, columns, err := newRecordRow(rows[logbookConfig.Row-1], logbookConfig.Record)
+	if err != nil {
+		return err
+	}
+
+	timeStyle, err := newTimeStyle(xls)
+	if err != nil {
+		return err
+	}
+
+	if err := setXLSXRow(xls, logbookConfig.sheet(), logbookConfig.Row, row, columns, timeStyle); err != nil {
+		return err
+	}
+
+	return xls.Save()
+}
+
+// deleteRecord removes the row with the record from the sheet
+func deleteRecord(logbookConfig LogbookConfig) ([]string, error) {
+	xls, rows, err := openXLSXSource(logbookConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkRowNumber(logbookConfig, rows); err != nil {
+		return nil, err
+	}
+
+	if err := xls.RemoveRow(logbookConfig.sheet(), logbookConfig.Row); err != nil {
+		return nil, err
+	}
+
+	return rows[logbookConfig.Row-1], xls.Save()
+}
+
+// AddRecord adds a new flight or simulator session to the xlsx logbook
+func AddRecord(logbookConfig LogbookConfig) {
+	rowNum, err := addRecord(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot add the record: %v", err)
+	}
+
+	fmt.Printf("Record has been added to the row %d\n", rowNum)
+}
+
+// EditRecord updates a flight or simulator session in the xlsx logbook
+func EditRecord(logbookConfig LogbookConfig) {
+	if err := editRecord(logbookConfig); err != nil {
+		log.Fatalf("Cannot update the record: %v", err)
+	}
+
+	fmt.Printf("Record in the row %d has been updated\n", logbookConfig.Row)
+}
+
+// DeleteRecord removes a flight or simulator session from the xlsx logbook
+func DeleteRecord(logbookConfig LogbookConfig) {
+	row, err := deleteRecord(logbookConfig)
+	if err != nil {
+		log.Fatalf("Cannot delete the record: %v", err)
+	}
+
+	fmt.Printf("Record in the row %d has been deleted: %s\n", logbookConfig.Row, strings.Join(row, " "))
+}
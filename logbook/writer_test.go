@@ -0,0 +1,139 @@
+package logbook
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestValidateRow(t *testing.T) {
+	row := []string{"08/10/2021", "LKSZ", "1410", "LKPR", "1540", "L200", "OK-NXX", "", "1:30", "", "1:30", "1", "", "", "", "1:30", "", "", "", "", "", "Self", ""}
+	assert.Equal(t, validateRow(row), nil)
+
+	row[0] = "2021-10-08"
+	assert.Equal(t, validateRow(row) != nil, true)
+	row[0] = "08/10/2021"
+
+	row[2] = "25:10"
+	assert.Equal(t, validateRow(row) != nil, true)
+	row[2] = "1410"
+
+	row[10] = "1h30"
+	assert.Equal(t, validateRow(row) != nil, true)
+	row[10] = ""
+	assert.Equal(t, validateRow(row) != nil, true)
+}
+
+func TestWriteRecords(t *testing.T) {
+	var record logbookRecord
+	record.date = "01/10/2021"
+	record.departure.place = "LKSZ"
+	record.arrival.place = "LKPR"
+	record.time.total.SetTime("1:00")
+
+	logbookConfig := LogbookConfig{
+		SourceType: "xlsx",
+		FileName:   filepath.Join(t.TempDir(), "logbook.xlsx"),
+		StartRow:   importStartRow,
+		Reverse:    true,
+	}
+
+	err := saveImportXLSX(logbookConfig.FileName, []logbookRecord{record})
+	assert.Equal(t, err, nil)
+
+	logbookConfig.Record = map[string]string{"date": "02/10/2021", "departure": "LKPR", "arrival": "LKSZ", "total": "1:15", "landings-day": "2"}
+	rowNum, err := addRecord(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowNum, importStartRow)
+
+	logbookConfig.Reverse = false
+	logbookConfig.Record = map[string]string{"date": "03/10/2021", "sim-type": "FNPT", "sim-time": "2:00"}
+	rowNum, err = addRecord(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowNum, importStartRow+2)
+
+	logbookConfig.Record = map[string]string{"date": "03/10/2021", "sim-type": "FNPT"}
+	_, err = addRecord(logbookConfig)
+	assert.Equal(t, err != nil, true)
+
+	values, err := getLogbookDump(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(values), 3)
+	record = parseRecord(values[0])
+	assert.Equal(t, record.date, "02/10/2021")
+	assert.Equal(t, record.time.total.GetTime(), "1:15")
+	assert.Equal(t, record.landings.day, 2)
+	assert.Equal(t, parseRecord(values[1]).date, "01/10/2021")
+	assert.Equal(t, parseRecord(values[2]).sim.name, "FNPT")
+
+	logbookConfig.Row = importStartRow + 1
+	logbookConfig.Record = map[string]string{"total": "0:50", "remarks": "edited"}
+	assert.Equal(t, editRecord(logbookConfig), nil)
+
+	logbookConfig.Row = importStartRow
+	_, err = deleteRecord(logbookConfig)
+	assert.Equal(t, err, nil)
+
+	logbookConfig.Row = importStartRow + 5
+	_, err = deleteRecord(logbookConfig)
+	assert.Equal(t, err != nil, true)
+
+	values, _ = getLogbookDump(logbookConfig)
+	assert.Equal(t, len(values), 2)
+	record = parseRecord(values[0])
+	assert.Equal(t, record.date, "01/10/2021")
+	assert.Equal(t, record.departure.place, "LKSZ")
+	assert.Equal(t, record.time.total.GetTime(), "0:50")
+	assert.Equal(t, record.remarks, "edited")
+}
+
+func TestAddRecordAboveTotals(t *testing.T) {
+	var record logbookRecord
+	record.date = "01/10/2021"
+	record.departure.place = "LKSZ"
+	record.arrival.place = "LKPR"
+	record.time.total.SetTime("1:00")
+
+	logbookConfig := LogbookConfig{
+		SourceType: "xlsx",
+		FileName:   filepath.Join(t.TempDir(), "logbook.xlsx"),
+		StartRow:   importStartRow,
+	}
+
+	err := saveImportXLSX(logbookConfig.FileName, []logbookRecord{record, record})
+	assert.Equal(t, err, nil)
+
+	// totals and signature rows below the records
+	xls, err := excelize.OpenFile(logbookConfig.FileName)
+	assert.Equal(t, err, nil)
+	xls.SetCellStr(sheetName, fmt.Sprintf("A%d", importStartRow+2), "Total")
+	xls.SetCellFormula(sheetName, fmt.Sprintf("K%d", importStartRow+2), fmt.Sprintf("SUM(K%d:K%d)", importStartRow, importStartRow+1))
+	xls.SetCellStr(sheetName, fmt.Sprintf("A%d", importStartRow+4), "Signature")
+	assert.Equal(t, xls.Save(), nil)
+
+	logbookConfig.Record = map[string]string{"date": "02/10/2021", "departure": "LKPR", "arrival": "LKSZ", "total": "1:15"}
+	rowNum, err := addRecord(logbookConfig)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowNum, importStartRow+2)
+
+	xls, err = excelize.OpenFile(logbookConfig.FileName)
+	assert.Equal(t, err, nil)
+	rows, err := xls.GetRows(sheetName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rows[importStartRow+1][0], "02/10/2021")
+	assert.Equal(t, rows[importStartRow+2][0], "Total")
+	assert.Equal(t, rows[importStartRow+4][0], "Signature")
+
+	// the records are written to the source file only
+	logbookConfig.Sources = []SourceConfig{{SourceType: "xlsx", FileName: logbookConfig.FileName}}
+	_, err = addRecord(logbookConfig)
+	assert.Equal(t, err != nil, true)
+
+	logbookConfig.Row = importStartRow
+	assert.Equal(t, editRecord(logbookConfig) != nil, true)
+	_, err = deleteRecord(logbookConfig)
+	assert.Equal(t, err != nil, true)
+}